@@ -0,0 +1,142 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fields validates and defaults the USER fields shared by all API
+// versions.
+package fields
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// maxEmailLength is the longest address allowed by RFC 5321.
+	maxEmailLength = 254
+	maxNameLength  = 64
+)
+
+// Namespace annotations supplying defaults for USER objects created in it.
+const (
+	DefaultLastNameAnnotation     = "users.reqres.in/default-last-name"
+	DefaultAvatarAnnotation       = "users.reqres.in/default-avatar"
+	DefaultSyncIntervalAnnotation = "users.reqres.in/default-sync-interval"
+)
+
+// NamespaceDefaults are the optional USER fields a namespace supplies. Empty
+// fields have no default.
+type NamespaceDefaults struct {
+	LastName     string
+	Avatar       string
+	SyncInterval *metav1.Duration
+}
+
+// ReadNamespaceDefaults reads the defaults of namespace from its annotations.
+// A sync interval that is not a positive duration is logged and dropped, as
+// validation would reject every user it was applied to.
+func ReadNamespaceDefaults(ctx context.Context, reader client.Reader, namespace string, log logr.Logger) (NamespaceDefaults, error) {
+	ns := &corev1.Namespace{}
+	if err := reader.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return NamespaceDefaults{}, fmt.Errorf("unable to read defaults of namespace %s: %w", namespace, err)
+	}
+	annotations := ns.GetAnnotations()
+	defaults := NamespaceDefaults{
+		LastName: strings.TrimSpace(annotations[DefaultLastNameAnnotation]),
+		Avatar:   strings.TrimSpace(annotations[DefaultAvatarAnnotation]),
+	}
+	if value := annotations[DefaultSyncIntervalAnnotation]; value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval <= 0 {
+			log.Info("ignoring invalid namespace default", "annotation", DefaultSyncIntervalAnnotation, "value", value)
+		} else {
+			defaults.SyncInterval = &metav1.Duration{Duration: interval}
+		}
+	}
+	return defaults, nil
+}
+
+// NormalizeEmail trims and lowercases email.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// DefaultAvatar points at the Gravatar identicon for email.
+func DefaultAvatar(email string) string {
+	hash := sha256.Sum256([]byte(email))
+	return "https://gravatar.com/avatar/" + hex.EncodeToString(hash[:]) + "?d=identicon"
+}
+
+// ValidateEmail accepts a bare RFC 5322 address, without display name.
+func ValidateEmail(email string, path *field.Path) field.ErrorList {
+	if email == "" {
+		return field.ErrorList{field.Required(path, "")}
+	}
+	if len(email) > maxEmailLength {
+		return field.ErrorList{field.TooLong(path, email, maxEmailLength)}
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Name != "" || addr.Address != email {
+		return field.ErrorList{field.Invalid(path, email, "must be a valid email address")}
+	}
+	return nil
+}
+
+// ValidateName allows letters, combining marks, spaces and the punctuation
+// found in personal names.
+func ValidateName(name string, required bool, path *field.Path) field.ErrorList {
+	if name == "" {
+		if required {
+			return field.ErrorList{field.Required(path, "")}
+		}
+		return nil
+	}
+	if utf8.RuneCountInString(name) > maxNameLength {
+		return field.ErrorList{field.TooLong(path, name, maxNameLength)}
+	}
+	for _, c := range name {
+		if unicode.IsLetter(c) || unicode.IsMark(c) || c == ' ' || c == '-' || c == '\'' || c == '.' {
+			continue
+		}
+		return field.ErrorList{field.Invalid(path, name, "may only contain letters, spaces, hyphens, apostrophes and periods")}
+	}
+	return nil
+}
+
+// ValidateAvatar accepts an empty avatar or an absolute http(s) URL.
+func ValidateAvatar(avatar string, path *field.Path) field.ErrorList {
+	if avatar == "" {
+		return nil
+	}
+	u, err := url.ParseRequestURI(avatar)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return field.ErrorList{field.Invalid(path, avatar, "must be an absolute http or https URL")}
+	}
+	return nil
+}
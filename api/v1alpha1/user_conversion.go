@@ -0,0 +1,277 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/adrafiq/reqres-controller/api/v1beta1"
+)
+
+// conversionDataAnnotation keeps the v1beta1 fields v1alpha1 cannot hold, so
+// that reading and writing an object through v1alpha1 does not lose them.
+const conversionDataAnnotation = "users.reqres.in/v1beta1-conversion-data"
+
+// conversionData holds the v1beta1 fields without a v1alpha1 counterpart.
+type conversionData struct {
+	ImportID               *string                         `json:"importID,omitempty"`
+	ExternalID             *string                         `json:"externalID,omitempty"`
+	ObservedGeneration     int64                           `json:"observedGeneration,omitempty"`
+	LastSyncTime           *metav1.Time                    `json:"lastSyncTime,omitempty"`
+	LastHandledSyncRequest string                          `json:"lastHandledSyncRequest,omitempty"`
+	AvatarFrom             *v1beta1.AvatarSource           `json:"avatarFrom,omitempty"`
+	AvatarHash             string                          `json:"avatarHash,omitempty"`
+	Backend                string                          `json:"backend,omitempty"`
+	BackendRef             *v1beta1.ReqresBackendReference `json:"backendRef,omitempty"`
+}
+
+// ConvertTo converts this USER to the Hub version (v1beta1).
+func (src *USER) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.USER)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	dst.Spec = v1beta1.USERSpec{
+		Email: src.Spec.Email,
+		Name: v1beta1.UserName{
+			First: src.Spec.FirstName,
+			Last:  src.Spec.LastName,
+		},
+		Avatar:           src.Spec.Avatar,
+		ImportID:         idToString(src.Spec.ImportId),
+		DeletionPolicy:   v1beta1.DeletionPolicy(src.Spec.DeletionPolicy),
+		ManagementPolicy: v1beta1.ManagementPolicy(src.Spec.ManagementPolicy),
+		SyncInterval:     src.Spec.SyncInterval.DeepCopy(),
+		DriftPolicy:      v1beta1.DriftPolicy(src.Spec.DriftPolicy),
+	}
+
+	dst.Status = v1beta1.USERStatus{
+		ExternalID:    externalIDToHub(src.Status.Id),
+		Conditions:    copyConditions(src.Status.Conditions),
+		DriftedFields: renameFields(src.Status.DriftedFields, hubFieldPaths),
+	}
+	if src.Status.AtProvider != nil {
+		dst.Status.AtProvider = &v1beta1.USERObservation{
+			Email: src.Status.AtProvider.Email,
+			Name: v1beta1.UserName{
+				First: src.Status.AtProvider.FirstName,
+				Last:  src.Status.AtProvider.LastName,
+			},
+			Avatar: src.Status.AtProvider.Avatar,
+		}
+	}
+
+	// Restore what v1alpha1 could not hold
+	data, ok := dst.Annotations[conversionDataAnnotation]
+	if !ok {
+		return nil
+	}
+	delete(dst.Annotations, conversionDataAnnotation)
+	if len(dst.Annotations) == 0 {
+		dst.Annotations = nil
+	}
+	var restored conversionData
+	if err := json.Unmarshal([]byte(data), &restored); err != nil {
+		return err
+	}
+	if restored.ImportID != nil {
+		dst.Spec.ImportID = *restored.ImportID
+	}
+	if restored.ExternalID != nil {
+		dst.Status.ExternalID = restored.ExternalID
+	}
+	dst.Status.ObservedGeneration = restored.ObservedGeneration
+	dst.Status.LastSyncTime = restored.LastSyncTime
+	dst.Status.LastHandledSyncRequest = restored.LastHandledSyncRequest
+	dst.Spec.AvatarFrom = restored.AvatarFrom
+	dst.Status.AvatarHash = restored.AvatarHash
+	dst.Status.Backend = restored.Backend
+	dst.Spec.BackendRef = restored.BackendRef
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1beta1) to this version.
+func (dst *USER) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.USER)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	importID, importLossless := idFromString(src.Spec.ImportID)
+	dst.Spec = USERSpec{
+		Email:            src.Spec.Email,
+		FirstName:        src.Spec.Name.First,
+		LastName:         src.Spec.Name.Last,
+		Avatar:           src.Spec.Avatar,
+		ImportId:         importID,
+		DeletionPolicy:   DeletionPolicy(src.Spec.DeletionPolicy),
+		ManagementPolicy: ManagementPolicy(src.Spec.ManagementPolicy),
+		SyncInterval:     src.Spec.SyncInterval.DeepCopy(),
+		DriftPolicy:      DriftPolicy(src.Spec.DriftPolicy),
+	}
+
+	externalID, externalLossless := externalIDFromHub(src.Status.ExternalID)
+	dst.Status = USERStatus{
+		Id:            externalID,
+		Conditions:    copyConditions(src.Status.Conditions),
+		DriftedFields: renameFields(src.Status.DriftedFields, spokeFieldPaths),
+	}
+	if src.Status.AtProvider != nil {
+		dst.Status.AtProvider = &USERObservation{
+			Email:     src.Status.AtProvider.Email,
+			FirstName: src.Status.AtProvider.Name.First,
+			LastName:  src.Status.AtProvider.Name.Last,
+			Avatar:    src.Status.AtProvider.Avatar,
+		}
+	}
+
+	// Keep what v1alpha1 cannot hold
+	data := conversionData{
+		ObservedGeneration:     src.Status.ObservedGeneration,
+		LastSyncTime:           src.Status.LastSyncTime.DeepCopy(),
+		LastHandledSyncRequest: src.Status.LastHandledSyncRequest,
+		AvatarFrom:             src.Spec.AvatarFrom.DeepCopy(),
+		AvatarHash:             src.Status.AvatarHash,
+		Backend:                src.Status.Backend,
+		BackendRef:             src.Spec.BackendRef.DeepCopy(),
+	}
+	if !importLossless {
+		data.ImportID = &src.Spec.ImportID
+	}
+	if !externalLossless {
+		data.ExternalID = src.Status.ExternalID
+	}
+	if data == (conversionData{}) {
+		return nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if dst.Annotations == nil {
+		dst.Annotations = map[string]string{}
+	}
+	dst.Annotations[conversionDataAnnotation] = string(raw)
+	return nil
+}
+
+// idToString maps the v1alpha1 int id, where 0 means unset, to an opaque id.
+func idToString(id int) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.Itoa(id)
+}
+
+// idFromString maps an opaque id to an int and reports whether converting
+// the result back yields the same id.
+func idFromString(id string) (int, bool) {
+	if id == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(id)
+	if err != nil || n == 0 {
+		return 0, false
+	}
+	return n, strconv.Itoa(n) == id
+}
+
+// externalIDToHub maps the v1alpha1 status id, where 0 means the backend
+// user does not exist yet, to the v1beta1 one, where that is nil.
+func externalIDToHub(id int) *string {
+	if id == 0 {
+		return nil
+	}
+	externalID := strconv.Itoa(id)
+	return &externalID
+}
+
+// externalIDFromHub is the inverse of externalIDToHub and reports whether it
+// was lossless.
+func externalIDFromHub(externalID *string) (int, bool) {
+	if externalID == nil {
+		return 0, true
+	}
+	if *externalID == "" {
+		return 0, false
+	}
+	return idFromString(*externalID)
+}
+
+// hasBackendUser reports whether the backend user exists, including when its
+// id cannot be held by v1alpha1 and was kept in the conversion data.
+func (r *USER) hasBackendUser() bool {
+	if r.Status.Id != 0 {
+		return true
+	}
+	data, ok := r.conversionData()
+	return ok && data.ExternalID != nil
+}
+
+// backendRefName returns the name of the v1beta1 backendRef kept in the
+// conversion data, or "" without one.
+func (r *USER) backendRefName() string {
+	data, ok := r.conversionData()
+	if !ok || data.BackendRef == nil {
+		return ""
+	}
+	return data.BackendRef.Name
+}
+
+// conversionData decodes the conversion data annotation, if r has one.
+func (r *USER) conversionData() (conversionData, bool) {
+	var data conversionData
+	raw, ok := r.Annotations[conversionDataAnnotation]
+	if !ok {
+		return data, false
+	}
+	return data, json.Unmarshal([]byte(raw), &data) == nil
+}
+
+// hubFieldPaths maps the spec fields of v1alpha1 named in
+// status.driftedFields to their path in v1beta1, and spokeFieldPaths back.
+var (
+	hubFieldPaths   = map[string]string{"firstName": "name.first", "lastName": "name.last"}
+	spokeFieldPaths = map[string]string{"name.first": "firstName", "name.last": "lastName"}
+)
+
+// renameFields copies fields, renamed per paths.
+func renameFields(fields []string, paths map[string]string) []string {
+	if len(fields) == 0 {
+		return nil
+	}
+	renamed := make([]string, len(fields))
+	for i, field := range fields {
+		if path, ok := paths[field]; ok {
+			field = path
+		}
+		renamed[i] = field
+	}
+	return renamed
+}
+
+func copyConditions(conditions []metav1.Condition) []metav1.Condition {
+	if conditions == nil {
+		return nil
+	}
+	out := make([]metav1.Condition, len(conditions))
+	for i := range conditions {
+		conditions[i].DeepCopyInto(&out[i])
+	}
+	return out
+}
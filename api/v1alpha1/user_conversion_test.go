@@ -0,0 +1,150 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	fuzz "github.com/google/gofuzz"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/diff"
+
+	"github.com/adrafiq/reqres-controller/api/v1beta1"
+)
+
+// fuzzSeed keeps the round trip tests reproducible. Other seeds are tried
+// with e.g. go test ./api/v1alpha1 -args -fuzz-seed=$RANDOM.
+var fuzzSeed = flag.Int64("fuzz-seed", 1, "seed of the conversion round trip fuzzer")
+
+// fuzzer fills objects with random data. Times are whole seconds, which is
+// all the API server keeps of them, and TypeMeta is left to the webhook.
+func fuzzer(t *testing.T) *fuzz.Fuzzer {
+	t.Logf("fuzz seed %d", *fuzzSeed)
+	return fuzz.NewWithSeed(*fuzzSeed).NilChance(0.2).Funcs(
+		func(tm *metav1.TypeMeta, c fuzz.Continue) {},
+		func(tm *metav1.Time, c fuzz.Continue) {
+			*tm = metav1.Unix(c.Int63n(1<<32), 0)
+		},
+		func(id *string, c fuzz.Continue) {
+			// Mix numeric ids with opaque ones so both conversion paths run
+			switch c.Intn(3) {
+			case 0:
+				*id = ""
+			case 1:
+				*id = c.RandString()
+			default:
+				*id = time.Unix(c.Int63n(1<<32), 0).Format("20060102150405")
+			}
+		},
+	)
+}
+
+func TestUSERRoundTripFromSpoke(t *testing.T) {
+	f := fuzzer(t)
+	for i := 0; i < 1000; i++ {
+		original := &USER{}
+		f.Fuzz(original)
+		delete(original.Annotations, conversionDataAnnotation)
+
+		hub := &v1beta1.USER{}
+		if err := original.DeepCopy().ConvertTo(hub); err != nil {
+			t.Fatalf("ConvertTo: %v", err)
+		}
+		roundTripped := &USER{}
+		if err := roundTripped.ConvertFrom(hub); err != nil {
+			t.Fatalf("ConvertFrom: %v", err)
+		}
+		if !apiequality.Semantic.DeepEqual(original, roundTripped) {
+			t.Fatalf("v1alpha1 -> v1beta1 -> v1alpha1 is lossy:\n%s", diff.ObjectReflectDiff(original, roundTripped))
+		}
+	}
+}
+
+func TestUSERRoundTripFromHub(t *testing.T) {
+	f := fuzzer(t)
+	for i := 0; i < 1000; i++ {
+		original := &v1beta1.USER{}
+		f.Fuzz(original)
+		delete(original.Annotations, conversionDataAnnotation)
+
+		spoke := &USER{}
+		if err := spoke.ConvertFrom(original.DeepCopy()); err != nil {
+			t.Fatalf("ConvertFrom: %v", err)
+		}
+		roundTripped := &v1beta1.USER{}
+		if err := spoke.ConvertTo(roundTripped); err != nil {
+			t.Fatalf("ConvertTo: %v", err)
+		}
+		if !apiequality.Semantic.DeepEqual(original, roundTripped) {
+			t.Fatalf("v1beta1 -> v1alpha1 -> v1beta1 is lossy:\n%s", diff.ObjectReflectDiff(original, roundTripped))
+		}
+	}
+}
+
+// TestValidateUpdateKeepsBackendRef checks that the backendRef v1alpha1 only
+// holds in the conversion data cannot be changed through it either.
+func TestValidateUpdateKeepsBackendRef(t *testing.T) {
+	id := "opaque-id"
+	hub := &v1beta1.USER{
+		ObjectMeta: metav1.ObjectMeta{Name: "janet", Namespace: "default"},
+		Spec: v1beta1.USERSpec{
+			Email:      "janet.weaver@reqres.in",
+			Name:       v1beta1.UserName{First: "Janet"},
+			BackendRef: &v1beta1.ReqresBackendReference{Name: "staging"},
+		},
+		Status: v1beta1.USERStatus{ExternalID: &id},
+	}
+	old := &USER{}
+	if err := old.ConvertFrom(hub.DeepCopy()); err != nil {
+		t.Fatalf("ConvertFrom: %v", err)
+	}
+	hub.Spec.BackendRef.Name = "production"
+	user := &USER{}
+	if err := user.ConvertFrom(hub); err != nil {
+		t.Fatalf("ConvertFrom: %v", err)
+	}
+
+	if err := user.ValidateUpdate(old); err == nil {
+		t.Error("ValidateUpdate() accepted a new backendRef for an existing backend user")
+	}
+	if err := old.DeepCopy().ValidateUpdate(old); err != nil {
+		t.Errorf("ValidateUpdate() of an unchanged user = %v", err)
+	}
+}
+
+// TestDriftedFieldsAreRenamed checks that status.driftedFields names the
+// spec fields of the version it is served in.
+func TestDriftedFieldsAreRenamed(t *testing.T) {
+	hub := &v1beta1.USER{Status: v1beta1.USERStatus{DriftedFields: []string{"email", "name.first", "name.last"}}}
+	spoke := &USER{}
+	if err := spoke.ConvertFrom(hub); err != nil {
+		t.Fatalf("ConvertFrom: %v", err)
+	}
+	if want := []string{"email", "firstName", "lastName"}; !apiequality.Semantic.DeepEqual(spoke.Status.DriftedFields, want) {
+		t.Errorf("v1alpha1 driftedFields = %v, want %v", spoke.Status.DriftedFields, want)
+	}
+	roundTripped := &v1beta1.USER{}
+	if err := spoke.ConvertTo(roundTripped); err != nil {
+		t.Fatalf("ConvertTo: %v", err)
+	}
+	if !apiequality.Semantic.DeepEqual(roundTripped.Status.DriftedFields, hub.Status.DriftedFields) {
+		t.Errorf("v1beta1 driftedFields = %v, want %v", roundTripped.Status.DriftedFields, hub.Status.DriftedFields)
+	}
+}
@@ -23,6 +23,74 @@ import (
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// DeletionPolicy decides what happens to the backend user when its USER is
+// deleted.
+// +kubebuilder:validation:Enum=Delete;Orphan;Retain
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the backend user.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyOrphan keeps the backend user and stops managing it.
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+	// DeletionPolicyRetain keeps the backend user, e.g. to import it again
+	// later through spec.importId. It behaves like Orphan.
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+)
+
+// DeletesBackend reports whether the backend user is deleted with the USER.
+// Objects created before the policy existed have none and are deleted.
+func (p DeletionPolicy) DeletesBackend() bool {
+	return p == "" || p == DeletionPolicyDelete
+}
+
+// ManagementPolicy decides which backend operations the controller may make.
+// +kubebuilder:validation:Enum=Full;ObserveOnly;CreateOnly
+type ManagementPolicy string
+
+const (
+	// ManagementPolicyFull creates, updates and deletes the backend user.
+	ManagementPolicyFull ManagementPolicy = "Full"
+	// ManagementPolicyObserveOnly only reads the backend user, which must be
+	// referenced through spec.importId, and mirrors it into status.atProvider.
+	ManagementPolicyObserveOnly ManagementPolicy = "ObserveOnly"
+	// ManagementPolicyCreateOnly creates the backend user but never updates
+	// or deletes it.
+	ManagementPolicyCreateOnly ManagementPolicy = "CreateOnly"
+)
+
+// CanCreate reports whether the controller may create the backend user.
+func (p ManagementPolicy) CanCreate() bool {
+	return p != ManagementPolicyObserveOnly
+}
+
+// CanUpdate reports whether the controller may patch the backend user.
+func (p ManagementPolicy) CanUpdate() bool {
+	return p == "" || p == ManagementPolicyFull
+}
+
+// CanDelete reports whether the controller may delete the backend user.
+func (p ManagementPolicy) CanDelete() bool {
+	return p == "" || p == ManagementPolicyFull
+}
+
+// DriftPolicy decides what happens when the backend user no longer matches
+// the spec.
+// +kubebuilder:validation:Enum=Correct;Report
+type DriftPolicy string
+
+const (
+	// DriftPolicyCorrect reverts the backend user to the spec.
+	DriftPolicyCorrect DriftPolicy = "Correct"
+	// DriftPolicyReport only records the drifted fields in status.
+	DriftPolicyReport DriftPolicy = "Report"
+)
+
+// Corrects reports whether drift is reverted in the backend.
+func (p DriftPolicy) Corrects() bool {
+	return p == "" || p == DriftPolicyCorrect
+}
+
 // USERSpec defines the desired state of USER
 type USERSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
@@ -34,13 +102,63 @@ type USERSpec struct {
 	FirstName string `json:"firstName"`
 	LastName  string `json:"lastName,omitempty"`
 	Avatar    string `json:"avatar,omitempty"`
+
+	// ImportId adopts an existing backend user with this id instead of
+	// creating a new one. It is only read while the user has no status id.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ImportId int `json:"importId,omitempty"`
+
+	// DeletionPolicy decides whether the backend user is deleted with this
+	// object. Defaults to Delete.
+	// +optional
+	// +kubebuilder:default=Delete
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// ManagementPolicy limits what the controller does in the backend.
+	// Defaults to Full.
+	// +optional
+	// +kubebuilder:default=Full
+	ManagementPolicy ManagementPolicy `json:"managementPolicy,omitempty"`
+
+	// SyncInterval overrides how often the backend user is checked for
+	// drift, e.g. "5m". Defaults to the controller's REQRES_SYNC_INTERVAL.
+	// +optional
+	SyncInterval *metav1.Duration `json:"syncInterval,omitempty"`
+
+	// DriftPolicy decides whether drift found in the backend is reverted or
+	// only reported. Defaults to Correct.
+	// +optional
+	// +kubebuilder:default=Correct
+	DriftPolicy DriftPolicy `json:"driftPolicy,omitempty"`
 }
 
+// USERObservation is the user as last read from the backend.
+type USERObservation struct {
+	Email     string `json:"email,omitempty"`
+	FirstName string `json:"firstName,omitempty"`
+	LastName  string `json:"lastName,omitempty"`
+	Avatar    string `json:"avatar,omitempty"`
+}
+
+const (
+	// ConditionBackendAvailable is False while the controller holds off
+	// calling the backend because it is failing.
+	ConditionBackendAvailable = "BackendAvailable"
+)
+
 // USERStatus defines the observed state of USER
 type USERStatus struct {
 	// Uniqure Id generated by backend for this particular user.
 	Id         int                `json:"id"`
 	Conditions []metav1.Condition `json:"conditions"`
+	// AtProvider mirrors the user as last observed in the backend.
+	// +optional
+	AtProvider *USERObservation `json:"atProvider,omitempty"`
+	// DriftedFields lists the spec fields, e.g. firstName, that differed
+	// from the backend on the last sync.
+	// +optional
+	DriftedFields []string `json:"driftedFields,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -0,0 +1,200 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/adrafiq/reqres-controller/api/internal/fields"
+	"github.com/adrafiq/reqres-controller/api/v1beta1"
+)
+
+// log is for logging in this package.
+var userlog = logf.Log.WithName("user-resource")
+
+// Namespace annotations supplying defaults for USER objects created in it.
+const (
+	DefaultLastNameAnnotation     = v1beta1.DefaultLastNameAnnotation
+	DefaultAvatarAnnotation       = v1beta1.DefaultAvatarAnnotation
+	DefaultSyncIntervalAnnotation = v1beta1.DefaultSyncIntervalAnnotation
+)
+
+func (r *USER) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithDefaulter(&namespaceDefaulter{reader: mgr.GetAPIReader()}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-users-reqres-in-v1alpha1-user,mutating=true,failurePolicy=fail,sideEffects=None,groups=users.reqres.in,resources=users,verbs=create;update,versions=v1alpha1,name=muser.kb.io,admissionReviewVersions=v1
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get
+
+var _ webhook.Defaulter = &USER{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type.
+// It only normalises the spec; the avatar is defaulted on create alone.
+func (r *USER) Default() {
+	userlog.Info("default", "name", r.Name)
+	r.Spec.Email = fields.NormalizeEmail(r.Spec.Email)
+	r.Spec.FirstName = strings.TrimSpace(r.Spec.FirstName)
+	r.Spec.LastName = strings.TrimSpace(r.Spec.LastName)
+	r.Spec.Avatar = strings.TrimSpace(r.Spec.Avatar)
+}
+
+// wantsDefaultAvatar reports whether a new user gets a default avatar. Users
+// adopting a backend user, also by an id only the conversion data holds,
+// keep its avatar. A v1beta1 avatarFrom in the conversion data brings its
+// own, as v1beta1 writes are defaulted through this version too.
+func (r *USER) wantsDefaultAvatar() bool {
+	if strings.TrimSpace(r.Spec.Avatar) != "" || r.Spec.ImportId != 0 {
+		return false
+	}
+	data, _ := r.conversionData()
+	return data.ImportID == nil && data.AvatarFrom == nil
+}
+
+// namespaceDefaulter fills optional fields of new users from the annotations
+// of their namespace before applying USER.Default, and generates an avatar
+// for new users without one.
+type namespaceDefaulter struct {
+	reader client.Reader
+}
+
+var _ admission.CustomDefaulter = &namespaceDefaulter{}
+
+func (d *namespaceDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	user, ok := obj.(*USER)
+	if !ok {
+		return fmt.Errorf("expected a USER but got a %T", obj)
+	}
+	req, err := admission.RequestFromContext(ctx)
+	create := err == nil && req.Operation == admissionv1.Create
+	if create {
+		if err := d.applyNamespaceDefaults(ctx, user, req.Namespace); err != nil {
+			return err
+		}
+	}
+	user.Default()
+	// Updates, e.g. the controller's own patches, keep whatever avatar the
+	// backend user has
+	if create && user.wantsDefaultAvatar() && user.Spec.Email != "" {
+		user.Spec.Avatar = fields.DefaultAvatar(user.Spec.Email)
+	}
+	return nil
+}
+
+func (d *namespaceDefaulter) applyNamespaceDefaults(ctx context.Context, user *USER, namespace string) error {
+	defaults, err := fields.ReadNamespaceDefaults(ctx, d.reader, namespace, userlog)
+	if err != nil {
+		return err
+	}
+	if user.Spec.LastName == "" {
+		user.Spec.LastName = defaults.LastName
+	}
+	if defaults.Avatar != "" && user.wantsDefaultAvatar() {
+		user.Spec.Avatar = defaults.Avatar
+	}
+	if user.Spec.SyncInterval == nil {
+		user.Spec.SyncInterval = defaults.SyncInterval
+	}
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-users-reqres-in-v1alpha1-user,mutating=false,failurePolicy=fail,sideEffects=None,groups=users.reqres.in,resources=users,verbs=create;update,versions=v1alpha1,name=vuser.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &USER{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *USER) ValidateCreate() error {
+	userlog.Info("validate create", "name", r.Name)
+	return r.invalid(r.validateSpec())
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *USER) ValidateUpdate(old runtime.Object) error {
+	userlog.Info("validate update", "name", r.Name)
+	oldUser, ok := old.(*USER)
+	if !ok {
+		return r.invalid(r.validateSpec())
+	}
+	// The spec is only validated when it changes, so that users admitted
+	// under older rules can still be finalized and deleted
+	var allErrs field.ErrorList
+	if r.DeletionTimestamp == nil && !equality.Semantic.DeepEqual(r.Spec, oldUser.Spec) {
+		allErrs = r.validateSpec()
+	}
+	allErrs = append(allErrs, r.validateImmutable(oldUser)...)
+	return r.invalid(allErrs)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *USER) ValidateDelete() error {
+	return nil
+}
+
+func (r *USER) invalid(allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("USER").GroupKind(), r.Name, allErrs)
+}
+
+func (r *USER) validateSpec() field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+	allErrs = append(allErrs, fields.ValidateEmail(r.Spec.Email, specPath.Child("email"))...)
+	allErrs = append(allErrs, fields.ValidateName(r.Spec.FirstName, true, specPath.Child("firstName"))...)
+	allErrs = append(allErrs, fields.ValidateName(r.Spec.LastName, false, specPath.Child("lastName"))...)
+	allErrs = append(allErrs, fields.ValidateAvatar(r.Spec.Avatar, specPath.Child("avatar"))...)
+	return allErrs
+}
+
+// validateImmutable rejects changes the backend cannot follow. Once the
+// backend user exists, importId would point the object at another user and
+// the backend annotation or the v1beta1 backendRef at another backend.
+func (r *USER) validateImmutable(old *USER) field.ErrorList {
+	var allErrs field.ErrorList
+	if !old.hasBackendUser() {
+		return allErrs
+	}
+	if r.Spec.ImportId != old.Spec.ImportId {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "importId"),
+			"cannot be changed once the user exists in the backend"))
+	}
+	if r.Annotations[v1beta1.BackendAnnotation] != old.Annotations[v1beta1.BackendAnnotation] {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("metadata", "annotations").Key(v1beta1.BackendAnnotation),
+			"cannot be changed once the user exists in the backend"))
+	}
+	if r.backendRefName() != old.backendRefName() {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("metadata", "annotations").Key(conversionDataAnnotation),
+			"cannot change backendRef once the user exists in the backend"))
+	}
+	return allErrs
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/adrafiq/reqres-controller/api/v1beta1"
+)
+
+func newUser(name string, spec USERSpec) *USER {
+	return &USER{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       spec,
+	}
+}
+
+var _ = Describe("USER validating webhook", func() {
+	validSpec := func() USERSpec {
+		return USERSpec{
+			Email:     "janet.weaver@reqres.in",
+			FirstName: "Janet",
+			LastName:  "O'Weaver-Smith",
+			Avatar:    "https://reqres.in/img/faces/2-image.jpg",
+		}
+	}
+
+	It("admits a valid user", func() {
+		user := newUser("valid", validSpec())
+		Expect(k8sClient.Create(ctx, user)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, user)).To(Succeed())
+	})
+
+	DescribeTable("rejects an invalid spec",
+		func(mutate func(*USERSpec), field string) {
+			spec := validSpec()
+			mutate(&spec)
+			err := k8sClient.Create(ctx, newUser("invalid", spec))
+			Expect(apierrors.IsInvalid(err)).To(BeTrue(), "expected invalid, got %v", err)
+			Expect(err.Error()).To(ContainSubstring(field))
+		},
+		Entry("malformed email", func(s *USERSpec) { s.Email = "janet@" }, "spec.email"),
+		Entry("email with display name", func(s *USERSpec) { s.Email = "Janet <janet@reqres.in>" }, "spec.email"),
+		Entry("oversized email", func(s *USERSpec) { s.Email = strings.Repeat("a", 250) + "@reqres.in" }, "spec.email"),
+		Entry("oversized first name", func(s *USERSpec) { s.FirstName = strings.Repeat("a", 65) }, "spec.firstName"),
+		Entry("digits in first name", func(s *USERSpec) { s.FirstName = "Janet2" }, "spec.firstName"),
+		Entry("markup in last name", func(s *USERSpec) { s.LastName = "<b>Weaver</b>" }, "spec.lastName"),
+		Entry("relative avatar", func(s *USERSpec) { s.Avatar = "/img/faces/2-image.jpg" }, "spec.avatar"),
+		Entry("non-http avatar", func(s *USERSpec) { s.Avatar = "ftp://reqres.in/2-image.jpg" }, "spec.avatar"),
+	)
+
+	It("rejects changing importId once the backend user exists", func() {
+		spec := validSpec()
+		spec.ImportId = 2
+		user := newUser("imported", spec)
+		Expect(k8sClient.Create(ctx, user)).To(Succeed())
+		user.Status = USERStatus{Id: 2, Conditions: []metav1.Condition{}}
+		Expect(k8sClient.Status().Update(ctx, user)).To(Succeed())
+
+		user.Spec.ImportId = 3
+		err := k8sClient.Update(ctx, user)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue(), "expected invalid, got %v", err)
+		Expect(err.Error()).To(ContainSubstring("spec.importId"))
+		Expect(k8sClient.Delete(ctx, user)).To(Succeed())
+	})
+
+	It("rejects moving the user to another backend once it exists there", func() {
+		user := newUser("pinned", validSpec())
+		user.Annotations = map[string]string{v1beta1.BackendAnnotation: "staging"}
+		Expect(k8sClient.Create(ctx, user)).To(Succeed())
+		user.Annotations[v1beta1.BackendAnnotation] = "production"
+		Expect(k8sClient.Update(ctx, user)).To(Succeed())
+		user.Status = USERStatus{Id: 2, Conditions: []metav1.Condition{}}
+		Expect(k8sClient.Status().Update(ctx, user)).To(Succeed())
+
+		delete(user.Annotations, v1beta1.BackendAnnotation)
+		err := k8sClient.Update(ctx, user)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue(), "expected invalid, got %v", err)
+		Expect(err.Error()).To(ContainSubstring(v1beta1.BackendAnnotation))
+		Expect(k8sClient.Delete(ctx, user)).To(Succeed())
+	})
+})
+
+var _ = Describe("USER defaulting webhook", func() {
+	It("normalises the email and generates an avatar", func() {
+		user := newUser("normalised", USERSpec{Email: "  Janet.Weaver@ReqRes.in ", FirstName: "Janet"})
+		Expect(k8sClient.Create(ctx, user)).To(Succeed())
+		Expect(user.Spec.Email).To(Equal("janet.weaver@reqres.in"))
+		Expect(user.Spec.Avatar).To(HavePrefix("https://gravatar.com/avatar/"))
+		Expect(k8sClient.Delete(ctx, user)).To(Succeed())
+	})
+
+	It("fills optional fields from namespace annotations", func() {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name: "team-a",
+			Annotations: map[string]string{
+				DefaultLastNameAnnotation:     "Weaver",
+				DefaultAvatarAnnotation:       "https://reqres.in/img/faces/team-a.jpg",
+				DefaultSyncIntervalAnnotation: "5m",
+			},
+		}}
+		Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+
+		user := newUser("defaulted", USERSpec{Email: "janet@reqres.in", FirstName: "Janet"})
+		user.Namespace = ns.Name
+		Expect(k8sClient.Create(ctx, user)).To(Succeed())
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(user), user)).To(Succeed())
+		Expect(user.Spec.LastName).To(Equal("Weaver"))
+		Expect(user.Spec.Avatar).To(Equal("https://reqres.in/img/faces/team-a.jpg"))
+		Expect(user.Spec.SyncInterval).NotTo(BeNil())
+		Expect(user.Spec.SyncInterval.Duration.String()).To(Equal("5m0s"))
+		Expect(k8sClient.Delete(ctx, user)).To(Succeed())
+	})
+
+	It("leaves the avatar of imported and existing users to the backend", func() {
+		user := newUser("adopted", USERSpec{Email: "janet@reqres.in", FirstName: "Janet", ImportId: 2})
+		Expect(k8sClient.Create(ctx, user)).To(Succeed())
+		Expect(user.Spec.Avatar).To(BeEmpty())
+
+		user.Spec.ImportId = 0
+		user.Spec.LastName = "Weaver"
+		Expect(k8sClient.Update(ctx, user)).To(Succeed())
+		Expect(user.Spec.Avatar).To(BeEmpty())
+		Expect(k8sClient.Delete(ctx, user)).To(Succeed())
+	})
+})
+
+func TestValidateUpdateOfUserAdmittedUnderOlderRules(t *testing.T) {
+	old := newUser("janet", USERSpec{Email: "janet.weaver@reqres.in", FirstName: "Janet2"})
+	old.Finalizers = []string{"users.reqres.in/v1alpha1"}
+	now := metav1.Now()
+	old.DeletionTimestamp = &now
+	deleted := old.DeepCopy()
+	deleted.Finalizers = nil
+	if err := deleted.ValidateUpdate(old); err != nil {
+		t.Errorf("ValidateUpdate() removing the finalizer = %v", err)
+	}
+
+	old.DeletionTimestamp = nil
+	changed := old.DeepCopy()
+	changed.Spec.LastName = "Weaver"
+	if err := changed.ValidateUpdate(old); !apierrors.IsInvalid(err) {
+		t.Errorf("ValidateUpdate() of a changed spec = %v, want invalid", err)
+	}
+}
+
+func TestDefaultAvatar(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		operation admissionv1.Operation
+		user      *USER
+		generated bool
+	}{{
+		name:      "create",
+		operation: admissionv1.Create,
+		user:      newUser("janet", USERSpec{Email: "janet@reqres.in"}),
+		generated: true,
+	}, {
+		name:      "update",
+		operation: admissionv1.Update,
+		user:      newUser("janet", USERSpec{Email: "janet@reqres.in"}),
+	}, {
+		name:      "import",
+		operation: admissionv1.Create,
+		user:      newUser("janet", USERSpec{Email: "janet@reqres.in", ImportId: 2}),
+	}, {
+		name:      "import by an opaque id",
+		operation: admissionv1.Create,
+		user: &USER{ObjectMeta: metav1.ObjectMeta{Name: "janet", Namespace: "default", Annotations: map[string]string{
+			conversionDataAnnotation: `{"importID":"4f1c-9a"}`,
+		}}, Spec: USERSpec{Email: "janet@reqres.in"}},
+	}, {
+		name:      "v1beta1 avatarFrom",
+		operation: admissionv1.Create,
+		user: &USER{ObjectMeta: metav1.ObjectMeta{Name: "janet", Namespace: "default", Annotations: map[string]string{
+			conversionDataAnnotation: `{"avatarFrom":{"secretKeyRef":{"name":"avatars","key":"janet.png"}}}`,
+		}}, Spec: USERSpec{Email: "janet@reqres.in"}},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := clientgoscheme.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+			).Build()
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Operation: tc.operation, Namespace: "default"}}
+			if err := (&namespaceDefaulter{reader: reader}).Default(admission.NewContextWithRequest(context.Background(), req), tc.user); err != nil {
+				t.Fatal(err)
+			}
+			if generated := tc.user.Spec.Avatar != ""; generated != tc.generated {
+				t.Errorf("avatar = %q, want generated %v", tc.user.Spec.Avatar, tc.generated)
+			}
+		})
+	}
+}
+
+func TestDefaultSyncInterval(t *testing.T) {
+	for _, tc := range []struct {
+		annotation string
+		want       string
+	}{
+		{annotation: "5m", want: "5m0s"},
+		{annotation: "0s"},
+		{annotation: "-1m"},
+		{annotation: "soon"},
+	} {
+		t.Run(tc.annotation, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := clientgoscheme.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Annotations: map[string]string{
+					DefaultSyncIntervalAnnotation: tc.annotation,
+				}}},
+			).Build()
+			user := newUser("janet", USERSpec{Email: "janet@reqres.in", FirstName: "Janet"})
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Create, Namespace: "default"}}
+			if err := (&namespaceDefaulter{reader: reader}).Default(admission.NewContextWithRequest(context.Background(), req), user); err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			if user.Spec.SyncInterval != nil {
+				got = user.Spec.SyncInterval.Duration.String()
+			}
+			if got != tc.want {
+				t.Errorf("syncInterval = %q, want %q", got, tc.want)
+			}
+			if err := user.ValidateCreate(); err != nil {
+				t.Errorf("defaulted user is invalid: %v", err)
+			}
+		})
+	}
+}
+
+var _ = Describe("USER conversion webhook", func() {
+	It("serves v1beta1 users as v1alpha1 and keeps the v1beta1 fields", func() {
+		user := &v1beta1.USER{
+			ObjectMeta: metav1.ObjectMeta{Name: "converted", Namespace: "default"},
+			Spec: v1beta1.USERSpec{
+				Email:      "janet.weaver@reqres.in",
+				Name:       v1beta1.UserName{First: "Janet", Last: "Weaver"},
+				BackendRef: &v1beta1.ReqresBackendReference{Name: "staging"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, user)).To(Succeed())
+
+		old := &USER{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(user), old)).To(Succeed())
+		Expect(old.Spec.FirstName).To(Equal("Janet"))
+		old.Spec.LastName = "Weaver-Smith"
+		Expect(k8sClient.Update(ctx, old)).To(Succeed())
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(user), user)).To(Succeed())
+		Expect(user.Spec.Name.Last).To(Equal("Weaver-Smith"))
+		Expect(user.Spec.BackendRef).To(Equal(&v1beta1.ReqresBackendReference{Name: "staging"}))
+		Expect(k8sClient.Delete(ctx, user)).To(Succeed())
+	})
+
+	It("validates v1beta1 users", func() {
+		user := &v1beta1.USER{
+			ObjectMeta: metav1.ObjectMeta{Name: "invalid", Namespace: "default"},
+			Spec: v1beta1.USERSpec{
+				Email:      "janet.weaver@reqres.in",
+				Name:       v1beta1.UserName{First: "Janet"},
+				AvatarFrom: &v1beta1.AvatarSource{},
+			},
+		}
+		err := k8sClient.Create(ctx, user)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue(), "expected invalid, got %v", err)
+		Expect(err.Error()).To(ContainSubstring("spec.avatarFrom"))
+	})
+})
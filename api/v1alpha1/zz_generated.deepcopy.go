@@ -31,7 +31,7 @@ func (in *USER) DeepCopyInto(out *USER) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -85,9 +85,29 @@ func (in *USERList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *USERObservation) DeepCopyInto(out *USERObservation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new USERObservation.
+func (in *USERObservation) DeepCopy() *USERObservation {
+	if in == nil {
+		return nil
+	}
+	out := new(USERObservation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *USERSpec) DeepCopyInto(out *USERSpec) {
 	*out = *in
+	if in.SyncInterval != nil {
+		in, out := &in.SyncInterval, &out.SyncInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new USERSpec.
@@ -110,6 +130,16 @@ func (in *USERStatus) DeepCopyInto(out *USERStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.AtProvider != nil {
+		in, out := &in.AtProvider, &out.AtProvider
+		*out = new(USERObservation)
+		**out = **in
+	}
+	if in.DriftedFields != nil {
+		in, out := &in.DriftedFields, &out.DriftedFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new USERStatus.
@@ -0,0 +1,152 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BackendOperation describes one HTTP call of a BackendProfile.
+//
+// Path and body are Go templates executed with the backend user at hand:
+// .ID, .User (with .Email, .FirstName, .LastName and .Avatar), .Attributes
+// (the backend attributes being sent), .IdempotencyKey, .Page and .PerPage.
+// The json function encodes a value, e.g. {"mail": {{ json .User.Email }}},
+// and urlquery escapes one for a query string.
+type BackendOperation struct {
+	// +kubebuilder:validation:Enum=GET;POST;PUT;PATCH;DELETE
+	Method string `json:"method"`
+	// Path is the template of the path below baseURL, e.g.
+	// "/api/users/{{ .ID }}". The id is path escaped.
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+	// Body is the template of the request body. Defaults to a JSON object of
+	// .Attributes for create and update, and to none otherwise.
+	// +optional
+	Body string `json:"body,omitempty"`
+	// SuccessCodes are the response statuses the call succeeded with.
+	// +kubebuilder:validation:MinItems=1
+	SuccessCodes []int `json:"successCodes"`
+	// Response locates the user in the response body.
+	// +optional
+	Response BackendResponse `json:"response,omitempty"`
+}
+
+// BackendResponse locates a user in a response body with JSONPath
+// expressions, e.g. "{.data}".
+type BackendResponse struct {
+	// User is the object holding the user's attributes. Defaults to the
+	// whole body.
+	// +optional
+	User string `json:"user,omitempty"`
+	// ID is the user id, relative to user. Defaults to "{.id}".
+	// +optional
+	ID string `json:"id,omitempty"`
+	// Items is the array of users in a list response. Defaults to the whole
+	// body.
+	// +optional
+	Items string `json:"items,omitempty"`
+	// TotalPages is the page count of a list response. Without it, a page
+	// shorter than requested is the last.
+	// +optional
+	TotalPages string `json:"totalPages,omitempty"`
+}
+
+// BackendOperations are the calls managing users in a backend.
+type BackendOperations struct {
+	Create BackendOperation `json:"create"`
+	Get    BackendOperation `json:"get"`
+	Update BackendOperation `json:"update"`
+	Delete BackendOperation `json:"delete"`
+	// List finds users by email, which recovers users whose create was
+	// interrupted. Without it, such users may be created twice.
+	// +optional
+	List *BackendOperation `json:"list,omitempty"`
+}
+
+// BackendProfileSpec describes how users are managed in a REST API.
+type BackendProfileSpec struct {
+	// BaseURL is prepended to the path of every operation.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	BaseURL string `json:"baseURL"`
+	// Attributes names the backend attribute of each USER field, keyed by
+	// email, firstName, lastName and avatar. Fields without one are neither
+	// sent nor read.
+	Attributes map[string]string `json:"attributes"`
+	// Headers are sent with every request.
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+	// SupportsIdempotencyKey declares that the API recognises a repeated
+	// create by its Idempotency-Key header, which makes failed creates safe
+	// to retry. Leave it unset unless the API documents it, as retrying a
+	// create the API acted on creates the user twice.
+	// +optional
+	SupportsIdempotencyKey bool `json:"supportsIdempotencyKey,omitempty"`
+	// Operations are the calls managing users.
+	Operations BackendOperations `json:"operations"`
+}
+
+// BackendProfileStatus defines the observed state of BackendProfile
+type BackendProfileStatus struct {
+	// ObservedGeneration is the generation of the spec last reconciled.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// Condition reasons of a BackendProfile.
+const (
+	ReasonProfileRegistered = "Registered"
+	ReasonInvalidProfile    = "InvalidProfile"
+	ReasonNameTaken         = "NameTaken"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Base URL",type=string,JSONPath=`.spec.baseURL`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// BackendProfile describes a REST API users can be managed in without
+// writing a backend in Go. USERs select it by name through the
+// users.reqres.in/backend annotation.
+type BackendProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BackendProfileSpec   `json:"spec,omitempty"`
+	Status BackendProfileStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// BackendProfileList contains a list of BackendProfile
+type BackendProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BackendProfile `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BackendProfile{}, &BackendProfileList{})
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReqresBackendReference selects a ReqresBackend in the USER's namespace.
+type ReqresBackendReference struct {
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// BackendAPIKey is sent with every request to authenticate.
+type BackendAPIKey struct {
+	// SecretKeyRef selects the key in a Secret of the backend's namespace.
+	SecretKeyRef corev1.SecretKeySelector `json:"secretKeyRef"`
+	// Header carries the key. Defaults to x-api-key.
+	// +optional
+	// +kubebuilder:default=x-api-key
+	Header string `json:"header,omitempty"`
+}
+
+// BackendTLS configures how the backend's certificate is verified and the
+// client certificate presented to it.
+type BackendTLS struct {
+	// CASecretRef selects PEM certificates the backend's certificate is
+	// verified against instead of the system roots.
+	// +optional
+	CASecretRef *corev1.SecretKeySelector `json:"caSecretRef,omitempty"`
+	// ClientCertificateSecretRef names a kubernetes.io/tls Secret whose
+	// tls.crt and tls.key are presented to the backend.
+	// +optional
+	ClientCertificateSecretRef *corev1.LocalObjectReference `json:"clientCertificateSecretRef,omitempty"`
+	// ServerName overrides the name the backend's certificate is verified
+	// for.
+	// +optional
+	ServerName string `json:"serverName,omitempty"`
+	// InsecureSkipVerify disables verifying the backend's certificate. Only
+	// meant for testing.
+	// +optional
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// BackendRateLimit bounds the requests sent to the backend by all USERs
+// selecting it.
+type BackendRateLimit struct {
+	// +kubebuilder:validation:Minimum=1
+	QPS int32 `json:"qps"`
+	// Burst defaults to qps.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Burst int32 `json:"burst,omitempty"`
+}
+
+// BackendTimeouts bound each attempt of an operation. Unset ones default to
+// the controller's REQRES_*_TIMEOUT.
+type BackendTimeouts struct {
+	// +optional
+	Create *metav1.Duration `json:"create,omitempty"`
+	// +optional
+	Get *metav1.Duration `json:"get,omitempty"`
+	// +optional
+	Update *metav1.Duration `json:"update,omitempty"`
+	// +optional
+	Delete *metav1.Duration `json:"delete,omitempty"`
+}
+
+// ReqresBackendSpec defines the desired state of ReqresBackend
+type ReqresBackendSpec struct {
+	// URL is the root of the reqres API, e.g. https://reqres.in.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url"`
+	// +optional
+	APIKey *BackendAPIKey `json:"apiKey,omitempty"`
+	// +optional
+	TLS *BackendTLS `json:"tls,omitempty"`
+	// RateLimit defaults to the controller's REQRES_RATE_LIMIT_QPS and
+	// REQRES_RATE_LIMIT_BURST, applied to this backend alone.
+	// +optional
+	RateLimit *BackendRateLimit `json:"rateLimit,omitempty"`
+	// +optional
+	Timeouts *BackendTimeouts `json:"timeouts,omitempty"`
+	// SupportsIdempotencyKey declares that the endpoint recognises a
+	// repeated create by its Idempotency-Key header, which makes failed
+	// creates safe to retry. reqres.in does not.
+	// +optional
+	SupportsIdempotencyKey bool `json:"supportsIdempotencyKey,omitempty"`
+}
+
+// ReqresBackendStatus defines the observed state of ReqresBackend
+type ReqresBackendStatus struct {
+	// ObservedGeneration is the generation of the spec last reconciled.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// Condition reasons of a ReqresBackend, besides ReasonAvailable and
+// ReasonCircuitOpen.
+const (
+	ReasonInvalidBackend = "InvalidBackend"
+	ReasonUnreachable    = "Unreachable"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.spec.url`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ReqresBackend is a reqres API endpoint along with the credentials and
+// limits USERs of its namespace are managed with. USERs select it through
+// spec.backendRef. Its Ready condition reports the endpoint's health.
+type ReqresBackend struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ReqresBackendSpec   `json:"spec,omitempty"`
+	Status ReqresBackendStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ReqresBackendList contains a list of ReqresBackend
+type ReqresBackendList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ReqresBackend `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ReqresBackend{}, &ReqresBackendList{})
+}
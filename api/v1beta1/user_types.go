@@ -0,0 +1,290 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DeletionPolicy decides what happens to the backend user when its USER is
+// deleted.
+// +kubebuilder:validation:Enum=Delete;Orphan;Retain
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the backend user.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyOrphan keeps the backend user and stops managing it.
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+	// DeletionPolicyRetain keeps the backend user, e.g. to import it again
+	// later through spec.importID. It behaves like Orphan.
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+)
+
+// DeletesBackend reports whether the backend user is deleted with the USER.
+// Objects created before the policy existed have none and are deleted.
+func (p DeletionPolicy) DeletesBackend() bool {
+	return p == "" || p == DeletionPolicyDelete
+}
+
+// ManagementPolicy decides which backend operations the controller may make.
+// +kubebuilder:validation:Enum=Full;ObserveOnly;CreateOnly
+type ManagementPolicy string
+
+const (
+	// ManagementPolicyFull creates, updates and deletes the backend user.
+	ManagementPolicyFull ManagementPolicy = "Full"
+	// ManagementPolicyObserveOnly only reads the backend user, which must be
+	// referenced through spec.importID, and mirrors it into status.atProvider.
+	ManagementPolicyObserveOnly ManagementPolicy = "ObserveOnly"
+	// ManagementPolicyCreateOnly creates the backend user but never updates
+	// or deletes it.
+	ManagementPolicyCreateOnly ManagementPolicy = "CreateOnly"
+)
+
+// CanCreate reports whether the controller may create the backend user.
+func (p ManagementPolicy) CanCreate() bool {
+	return p != ManagementPolicyObserveOnly
+}
+
+// CanUpdate reports whether the controller may patch the backend user.
+func (p ManagementPolicy) CanUpdate() bool {
+	return p == "" || p == ManagementPolicyFull
+}
+
+// CanDelete reports whether the controller may delete the backend user.
+func (p ManagementPolicy) CanDelete() bool {
+	return p == "" || p == ManagementPolicyFull
+}
+
+// DriftPolicy decides what happens when the backend user no longer matches
+// the spec.
+// +kubebuilder:validation:Enum=Correct;Report
+type DriftPolicy string
+
+const (
+	// DriftPolicyCorrect reverts the backend user to the spec.
+	DriftPolicyCorrect DriftPolicy = "Correct"
+	// DriftPolicyReport only records the drifted fields in status.
+	DriftPolicyReport DriftPolicy = "Report"
+)
+
+// Corrects reports whether drift is reverted in the backend.
+func (p DriftPolicy) Corrects() bool {
+	return p == "" || p == DriftPolicyCorrect
+}
+
+// UserName is the personal name of a user.
+type UserName struct {
+	// +kubebuilder:validation:Required
+	First string `json:"first"`
+	// +optional
+	Last string `json:"last,omitempty"`
+}
+
+// AvatarSource selects image bytes held in the cluster as the avatar.
+// Exactly one of its fields must be set.
+type AvatarSource struct {
+	// ConfigMapKeyRef selects a key of a ConfigMap in the USER's namespace.
+	// Both binaryData and data are looked up.
+	// +optional
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+	// SecretKeyRef selects a key of a Secret in the USER's namespace.
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+}
+
+// USERSpec defines the desired state of USER
+type USERSpec struct {
+	// +kubebuilder:validation:Required
+	Email string `json:"email"`
+	// +kubebuilder:validation:Required
+	Name UserName `json:"name"`
+	// +optional
+	Avatar string `json:"avatar,omitempty"`
+	// AvatarFrom uploads an image held in the cluster as the avatar, inlined
+	// as a data URL. It takes precedence over avatar.
+	// +optional
+	AvatarFrom *AvatarSource `json:"avatarFrom,omitempty"`
+
+	// ImportID adopts an existing backend user with this id instead of
+	// creating a new one. It is only read while status.externalID is unset.
+	// +optional
+	ImportID string `json:"importID,omitempty"`
+
+	// DeletionPolicy decides whether the backend user is deleted with this
+	// object. Defaults to Delete.
+	// +optional
+	// +kubebuilder:default=Delete
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// ManagementPolicy limits what the controller does in the backend.
+	// Defaults to Full.
+	// +optional
+	// +kubebuilder:default=Full
+	ManagementPolicy ManagementPolicy `json:"managementPolicy,omitempty"`
+
+	// SyncInterval overrides how often the backend user is checked for
+	// drift, e.g. "5m". Defaults to the controller's REQRES_SYNC_INTERVAL.
+	// +optional
+	SyncInterval *metav1.Duration `json:"syncInterval,omitempty"`
+
+	// DriftPolicy decides whether drift found in the backend is reverted or
+	// only reported. Defaults to Correct.
+	// +optional
+	// +kubebuilder:default=Correct
+	DriftPolicy DriftPolicy `json:"driftPolicy,omitempty"`
+
+	// BackendRef manages the user in a ReqresBackend of this namespace. It
+	// takes precedence over the backend annotation and, like it, cannot be
+	// changed once the user exists in a backend.
+	// +optional
+	BackendRef *ReqresBackendReference `json:"backendRef,omitempty"`
+}
+
+// USERObservation is the user as last read from the backend.
+type USERObservation struct {
+	Email string   `json:"email,omitempty"`
+	Name  UserName `json:"name,omitempty"`
+	// Avatar is left empty for an image inlined as a data URL, whose hash
+	// is status.avatarHash.
+	// +optional
+	Avatar string `json:"avatar,omitempty"`
+}
+
+// SyncRequestAnnotation requests a sync with the backend ahead of the sync
+// interval whenever its value changes, e.g. to the current time.
+const SyncRequestAnnotation = "users.reqres.in/sync-requested-at"
+
+// BackendAnnotation selects, on a USER or its namespace, the backend the
+// user is managed in by the name it was registered under. The USER's own
+// annotation wins; without either the controller's default backend is used.
+const BackendAnnotation = "users.reqres.in/backend"
+
+// Condition types of a USER. They follow the conventions understood by
+// kstatus, so that Argo CD and Flux derive health from Ready.
+const (
+	// ConditionReady is True once the backend user exists and the last sync
+	// left it as the spec and policies ask for.
+	ConditionReady = "Ready"
+	// ConditionSynced is True when the last exchange with the backend
+	// succeeded, and False with the failure otherwise.
+	ConditionSynced = "Synced"
+	// ConditionDeleting is True while the object is deleted and the backend
+	// user is handled per deletion policy.
+	ConditionDeleting = "Deleting"
+	// ConditionBackendAvailable is False while the controller holds off
+	// calling the backend because it is failing, next to Synced with reason
+	// CircuitOpen. It turns True again on the next successful sync.
+	ConditionBackendAvailable = "BackendAvailable"
+)
+
+// Condition reasons of a USER.
+const (
+	ReasonAvailable         = "Available"
+	ReasonCreating          = "Creating"
+	ReasonDrifted           = "Drifted"
+	ReasonImportIDRequired  = "ImportIDRequired"
+	ReasonImportNotFound    = "ImportNotFound"
+	ReasonAvatarUnavailable = "AvatarUnavailable"
+	ReasonUnknownBackend    = "UnknownBackend"
+	ReasonBackendNotReady   = "BackendNotReady"
+	ReasonBackendNotFound   = "BackendUserNotFound"
+	ReasonReconcileSuccess  = "ReconcileSuccess"
+	ReasonBackendError      = "BackendError"
+	ReasonInvalidRequest    = "InvalidRequest"
+	ReasonCircuitOpen       = "CircuitOpen"
+	ReasonDeletingBackend   = "DeletingBackendUser"
+	ReasonOrphaning         = "OrphaningBackendUser"
+)
+
+// USERStatus defines the observed state of USER
+type USERStatus struct {
+	// ExternalID is the opaque id the backend assigned to this user. It is
+	// unset until the user exists in the backend.
+	// +optional
+	ExternalID *string `json:"externalID,omitempty"`
+	// Backend is the name of the backend the user is managed in, or
+	// ReqresBackend/<name> for one selected through spec.backendRef. It is
+	// kept once the user exists there, whatever the spec and annotations say.
+	// +optional
+	Backend string `json:"backend,omitempty"`
+	// ObservedGeneration is the generation of the spec last reconciled.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastSyncTime is when the backend user was last read or written.
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+	// LastHandledSyncRequest is the value of the sync request annotation
+	// at the last sync.
+	// +optional
+	LastHandledSyncRequest string `json:"lastHandledSyncRequest,omitempty"`
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+	// AtProvider mirrors the user as last observed in the backend.
+	// +optional
+	AtProvider *USERObservation `json:"atProvider,omitempty"`
+	// AvatarHash is the sha256 of the spec.avatarFrom image the last sync
+	// used. A different hash makes the next sync due.
+	// +optional
+	AvatarHash string `json:"avatarHash,omitempty"`
+	// DriftedFields lists the paths of the spec fields, e.g. name.first, that
+	// differed from the backend on the last sync.
+	// +optional
+	DriftedFields []string `json:"driftedFields,omitempty"`
+}
+
+// Created reports whether the backend user exists, i.e. has an id.
+func (s *USERStatus) Created() bool {
+	return s.ExternalID != nil
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+//+kubebuilder:printcolumn:name="Email",type=string,JSONPath=`.spec.email`
+//+kubebuilder:printcolumn:name="External ID",type=string,JSONPath=`.status.externalID`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Synced",type=string,JSONPath=`.status.conditions[?(@.type=="Synced")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// USER is the Schema for the users API
+type USER struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   USERSpec   `json:"spec,omitempty"`
+	Status USERStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// USERList contains a list of USER
+type USERList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []USER `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&USER{}, &USERList{})
+}
@@ -0,0 +1,299 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/adrafiq/reqres-controller/api/internal/fields"
+)
+
+// maxImportIDLength bounds spec.importID, which ends up in request paths.
+const maxImportIDLength = 253
+
+var userlog = logf.Log.WithName("user-resource")
+
+// Namespace annotations supplying defaults for USER objects created in it.
+const (
+	DefaultLastNameAnnotation     = fields.DefaultLastNameAnnotation
+	DefaultAvatarAnnotation       = fields.DefaultAvatarAnnotation
+	DefaultSyncIntervalAnnotation = fields.DefaultSyncIntervalAnnotation
+)
+
+// SetupWebhookWithManager registers the defaulting and validating webhooks
+// of v1beta1 and the conversion webhook served for all USER versions.
+func (r *USER) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithDefaulter(&namespaceDefaulter{reader: mgr.GetAPIReader()}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-users-reqres-in-v1beta1-user,mutating=true,failurePolicy=fail,sideEffects=None,groups=users.reqres.in,resources=users,verbs=create;update,versions=v1beta1,name=muser-v1beta1.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Defaulter = &USER{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type.
+// It only normalises the spec; the avatar is defaulted on create alone.
+func (r *USER) Default() {
+	userlog.Info("default", "name", r.Name)
+	r.Spec.Email = fields.NormalizeEmail(r.Spec.Email)
+	r.Spec.Name.First = strings.TrimSpace(r.Spec.Name.First)
+	r.Spec.Name.Last = strings.TrimSpace(r.Spec.Name.Last)
+	r.Spec.Avatar = strings.TrimSpace(r.Spec.Avatar)
+	r.Spec.ImportID = strings.TrimSpace(r.Spec.ImportID)
+}
+
+// wantsDefaultAvatar reports whether a new user gets a default avatar. Users
+// adopting a backend user keep its avatar, and avatarFrom brings its own.
+func (r *USER) wantsDefaultAvatar() bool {
+	return strings.TrimSpace(r.Spec.Avatar) == "" && r.Spec.AvatarFrom == nil && strings.TrimSpace(r.Spec.ImportID) == ""
+}
+
+// namespaceDefaulter fills optional fields of new users from the annotations
+// of their namespace before applying USER.Default, and generates an avatar
+// for new users without one.
+type namespaceDefaulter struct {
+	reader client.Reader
+}
+
+var _ admission.CustomDefaulter = &namespaceDefaulter{}
+
+func (d *namespaceDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	user, ok := obj.(*USER)
+	if !ok {
+		return fmt.Errorf("expected a USER but got a %T", obj)
+	}
+	req, err := admission.RequestFromContext(ctx)
+	create := err == nil && req.Operation == admissionv1.Create
+	if create {
+		if err := d.applyNamespaceDefaults(ctx, user, req.Namespace); err != nil {
+			return err
+		}
+	}
+	user.Default()
+	// Updates, e.g. the controller's own patches, keep whatever avatar the
+	// backend user has
+	if create && user.wantsDefaultAvatar() && user.Spec.Email != "" {
+		user.Spec.Avatar = fields.DefaultAvatar(user.Spec.Email)
+	}
+	return nil
+}
+
+func (d *namespaceDefaulter) applyNamespaceDefaults(ctx context.Context, user *USER, namespace string) error {
+	defaults, err := fields.ReadNamespaceDefaults(ctx, d.reader, namespace, userlog)
+	if err != nil {
+		return err
+	}
+	if user.Spec.Name.Last == "" {
+		user.Spec.Name.Last = defaults.LastName
+	}
+	if defaults.Avatar != "" && user.wantsDefaultAvatar() {
+		user.Spec.Avatar = defaults.Avatar
+	}
+	if user.Spec.SyncInterval == nil {
+		user.Spec.SyncInterval = defaults.SyncInterval
+	}
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-users-reqres-in-v1beta1-user,mutating=false,failurePolicy=fail,sideEffects=None,groups=users.reqres.in,resources=users,verbs=create;update,versions=v1beta1,name=vuser-v1beta1.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &USER{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *USER) ValidateCreate() error {
+	userlog.Info("validate create", "name", r.Name)
+	return r.invalid(r.validateSpec())
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *USER) ValidateUpdate(old runtime.Object) error {
+	userlog.Info("validate update", "name", r.Name)
+	oldUser, ok := old.(*USER)
+	if !ok {
+		return r.invalid(r.validateSpec())
+	}
+	// The spec is only validated when it changes, so that users admitted
+	// under older rules can still be finalized and deleted
+	var allErrs field.ErrorList
+	if r.DeletionTimestamp == nil && !equality.Semantic.DeepEqual(r.Spec, oldUser.Spec) {
+		allErrs = r.validateSpec()
+	}
+	allErrs = append(allErrs, r.validateImmutable(oldUser)...)
+	return r.invalid(allErrs)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *USER) ValidateDelete() error {
+	return nil
+}
+
+func (r *USER) invalid(allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("USER").GroupKind(), r.Name, allErrs)
+}
+
+func (r *USER) validateSpec() field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+	allErrs = append(allErrs, fields.ValidateEmail(r.Spec.Email, specPath.Child("email"))...)
+	allErrs = append(allErrs, fields.ValidateName(r.Spec.Name.First, true, specPath.Child("name", "first"))...)
+	allErrs = append(allErrs, fields.ValidateName(r.Spec.Name.Last, false, specPath.Child("name", "last"))...)
+	allErrs = append(allErrs, fields.ValidateAvatar(r.Spec.Avatar, specPath.Child("avatar"))...)
+	allErrs = append(allErrs, validateAvatarFrom(r.Spec.AvatarFrom, specPath.Child("avatarFrom"))...)
+	allErrs = append(allErrs, validateImportID(r.Spec.ImportID, specPath.Child("importID"))...)
+	allErrs = append(allErrs, validateBackendRef(r.Spec.BackendRef, specPath.Child("backendRef"))...)
+	if r.Spec.SyncInterval != nil && r.Spec.SyncInterval.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("syncInterval"), r.Spec.SyncInterval.Duration.String(), "must be positive"))
+	}
+	allErrs = append(allErrs, validateEnum(string(r.Spec.DeletionPolicy), specPath.Child("deletionPolicy"),
+		string(DeletionPolicyDelete), string(DeletionPolicyOrphan), string(DeletionPolicyRetain))...)
+	allErrs = append(allErrs, validateEnum(string(r.Spec.ManagementPolicy), specPath.Child("managementPolicy"),
+		string(ManagementPolicyFull), string(ManagementPolicyObserveOnly), string(ManagementPolicyCreateOnly))...)
+	allErrs = append(allErrs, validateEnum(string(r.Spec.DriftPolicy), specPath.Child("driftPolicy"),
+		string(DriftPolicyCorrect), string(DriftPolicyReport))...)
+	return allErrs
+}
+
+// validateImmutable rejects changes the backend cannot follow. Once the
+// backend user exists, importID would point the object at another user and
+// the backend annotation or backendRef at another backend.
+func (r *USER) validateImmutable(old *USER) field.ErrorList {
+	var allErrs field.ErrorList
+	if !old.Status.Created() {
+		return allErrs
+	}
+	if r.Spec.ImportID != old.Spec.ImportID {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "importID"),
+			"cannot be changed once the user exists in the backend"))
+	}
+	if r.Annotations[BackendAnnotation] != old.Annotations[BackendAnnotation] {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("metadata", "annotations").Key(BackendAnnotation),
+			"cannot be changed once the user exists in the backend"))
+	}
+	if backendRefName(r.Spec.BackendRef) != backendRefName(old.Spec.BackendRef) {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "backendRef"),
+			"cannot be changed once the user exists in the backend"))
+	}
+	return allErrs
+}
+
+// backendRefName returns the name ref points at, or "" without ref.
+func backendRefName(ref *ReqresBackendReference) string {
+	if ref == nil {
+		return ""
+	}
+	return ref.Name
+}
+
+// validateAvatarFrom requires exactly one complete key selector.
+func validateAvatarFrom(source *AvatarSource, path *field.Path) field.ErrorList {
+	if source == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	switch {
+	case source.ConfigMapKeyRef != nil && source.SecretKeyRef != nil:
+		allErrs = append(allErrs, field.Forbidden(path, "may not set both configMapKeyRef and secretKeyRef"))
+	case source.ConfigMapKeyRef != nil:
+		ref := source.ConfigMapKeyRef
+		allErrs = append(allErrs, validateKeySelector(ref.Name, ref.Key, path.Child("configMapKeyRef"))...)
+	case source.SecretKeyRef != nil:
+		ref := source.SecretKeyRef
+		allErrs = append(allErrs, validateKeySelector(ref.Name, ref.Key, path.Child("secretKeyRef"))...)
+	default:
+		allErrs = append(allErrs, field.Required(path, "must set one of configMapKeyRef or secretKeyRef"))
+	}
+	return allErrs
+}
+
+func validateKeySelector(name, key string, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if name == "" {
+		allErrs = append(allErrs, field.Required(path.Child("name"), ""))
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(name) {
+			allErrs = append(allErrs, field.Invalid(path.Child("name"), name, msg))
+		}
+	}
+	if key == "" {
+		allErrs = append(allErrs, field.Required(path.Child("key"), ""))
+	} else {
+		for _, msg := range validation.IsConfigMapKey(key) {
+			allErrs = append(allErrs, field.Invalid(path.Child("key"), key, msg))
+		}
+	}
+	return allErrs
+}
+
+// validateImportID accepts any opaque id without whitespace or control
+// characters, as backends assign ids of their own making.
+func validateImportID(id string, path *field.Path) field.ErrorList {
+	if len(id) > maxImportIDLength {
+		return field.ErrorList{field.TooLong(path, id, maxImportIDLength)}
+	}
+	if strings.IndexFunc(id, func(c rune) bool { return unicode.IsSpace(c) || unicode.IsControl(c) }) >= 0 {
+		return field.ErrorList{field.Invalid(path, id, "may not contain whitespace or control characters")}
+	}
+	return nil
+}
+
+func validateBackendRef(ref *ReqresBackendReference, path *field.Path) field.ErrorList {
+	if ref == nil {
+		return nil
+	}
+	if ref.Name == "" {
+		return field.ErrorList{field.Required(path.Child("name"), "")}
+	}
+	var allErrs field.ErrorList
+	for _, msg := range validation.IsDNS1123Subdomain(ref.Name) {
+		allErrs = append(allErrs, field.Invalid(path.Child("name"), ref.Name, msg))
+	}
+	return allErrs
+}
+
+// validateEnum accepts an unset value, left to the CRD default, or one of
+// allowed.
+func validateEnum(value string, path *field.Path, allowed ...string) field.ErrorList {
+	if value == "" {
+		return nil
+	}
+	for _, v := range allowed {
+		if value == v {
+			return nil
+		}
+	}
+	return field.ErrorList{field.NotSupported(path, value, allowed)}
+}
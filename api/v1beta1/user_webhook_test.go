@@ -0,0 +1,228 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func newWebhookUser() *USER {
+	return &USER{
+		ObjectMeta: metav1.ObjectMeta{Name: "janet", Namespace: "default"},
+		Spec: USERSpec{
+			Email:  "janet.weaver@reqres.in",
+			Name:   UserName{First: "Janet", Last: "O'Weaver-Smith"},
+			Avatar: "https://reqres.in/img/faces/2-image.jpg",
+		},
+	}
+}
+
+func TestValidateCreate(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		mutate func(*USERSpec)
+		field  string
+	}{
+		{"valid", func(*USERSpec) {}, ""},
+		{"avatar from a secret", func(s *USERSpec) {
+			s.AvatarFrom = &AvatarSource{SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "avatars"}, Key: "janet.png"}}
+		}, ""},
+		{"opaque import id", func(s *USERSpec) { s.ImportID = "4f1c-9a" }, ""},
+		{"malformed email", func(s *USERSpec) { s.Email = "janet@" }, "spec.email"},
+		{"digits in first name", func(s *USERSpec) { s.Name.First = "Janet2" }, "spec.name.first"},
+		{"markup in last name", func(s *USERSpec) { s.Name.Last = "<b>Weaver</b>" }, "spec.name.last"},
+		{"relative avatar", func(s *USERSpec) { s.Avatar = "/img/faces/2-image.jpg" }, "spec.avatar"},
+		{"empty avatarFrom", func(s *USERSpec) { s.AvatarFrom = &AvatarSource{} }, "spec.avatarFrom"},
+		{"both avatar sources", func(s *USERSpec) {
+			s.AvatarFrom = &AvatarSource{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "a"}, Key: "k"},
+				SecretKeyRef:    &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "a"}, Key: "k"},
+			}
+		}, "spec.avatarFrom"},
+		{"avatar key missing", func(s *USERSpec) {
+			s.AvatarFrom = &AvatarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "a"}}}
+		}, "spec.avatarFrom.configMapKeyRef.key"},
+		{"whitespace in import id", func(s *USERSpec) { s.ImportID = "4 2" }, "spec.importID"},
+		{"oversized import id", func(s *USERSpec) { s.ImportID = strings.Repeat("4", 254) }, "spec.importID"},
+		{"backendRef without name", func(s *USERSpec) { s.BackendRef = &ReqresBackendReference{} }, "spec.backendRef.name"},
+		{"invalid backendRef name", func(s *USERSpec) { s.BackendRef = &ReqresBackendReference{Name: "Staging_1"} }, "spec.backendRef.name"},
+		{"negative sync interval", func(s *USERSpec) { s.SyncInterval = &metav1.Duration{Duration: -time.Minute} }, "spec.syncInterval"},
+		{"unknown drift policy", func(s *USERSpec) { s.DriftPolicy = "Ignore" }, "spec.driftPolicy"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			user := newWebhookUser()
+			tc.mutate(&user.Spec)
+			err := user.ValidateCreate()
+			if tc.field == "" {
+				if err != nil {
+					t.Fatalf("ValidateCreate() = %v", err)
+				}
+				return
+			}
+			if !apierrors.IsInvalid(err) || !strings.Contains(err.Error(), tc.field) {
+				t.Fatalf("ValidateCreate() = %v, want %s invalid", err, tc.field)
+			}
+		})
+	}
+}
+
+func TestValidateUpdateImmutable(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		mutate func(*USER)
+		field  string
+	}{{
+		name:   "importID",
+		mutate: func(u *USER) { u.Spec.ImportID = "3" },
+		field:  "spec.importID",
+	}, {
+		name:   "backend annotation",
+		mutate: func(u *USER) { u.Annotations[BackendAnnotation] = "production" },
+		field:  BackendAnnotation,
+	}, {
+		name:   "backend annotation removed",
+		mutate: func(u *USER) { delete(u.Annotations, BackendAnnotation) },
+		field:  BackendAnnotation,
+	}, {
+		name:   "backendRef",
+		mutate: func(u *USER) { u.Spec.BackendRef.Name = "production" },
+		field:  "spec.backendRef",
+	}, {
+		name:   "backendRef removed",
+		mutate: func(u *USER) { u.Spec.BackendRef = nil },
+		field:  "spec.backendRef",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			old := newWebhookUser()
+			old.Annotations = map[string]string{BackendAnnotation: "staging"}
+			old.Spec.ImportID = "2"
+			old.Spec.BackendRef = &ReqresBackendReference{Name: "staging"}
+			user := old.DeepCopy()
+			tc.mutate(user)
+			if err := user.ValidateUpdate(old); err != nil {
+				t.Fatalf("ValidateUpdate() before the backend user exists = %v", err)
+			}
+
+			id := "2"
+			old.Status.ExternalID = &id
+			if err := user.ValidateUpdate(old); !apierrors.IsInvalid(err) || !strings.Contains(err.Error(), tc.field) {
+				t.Fatalf("ValidateUpdate() = %v, want %s invalid", err, tc.field)
+			}
+		})
+	}
+}
+
+func TestValidateUpdateOfUserAdmittedUnderOlderRules(t *testing.T) {
+	old := newWebhookUser()
+	old.Spec.Name.First = "Janet2"
+	old.Finalizers = []string{"users.reqres.in/v1alpha1"}
+
+	// The controller's finalizer patches leave the spec alone
+	user := old.DeepCopy()
+	user.Finalizers = append(user.Finalizers, "example.com/other")
+	if err := user.ValidateUpdate(old); err != nil {
+		t.Errorf("ValidateUpdate() of an unchanged spec = %v", err)
+	}
+
+	// Deleting it removes the finalizer
+	now := metav1.Now()
+	old.DeletionTimestamp = &now
+	deleted := old.DeepCopy()
+	deleted.Finalizers = nil
+	if err := deleted.ValidateUpdate(old); err != nil {
+		t.Errorf("ValidateUpdate() removing the finalizer = %v", err)
+	}
+
+	// A changed spec must follow the current rules
+	old.DeletionTimestamp = nil
+	changed := old.DeepCopy()
+	changed.Spec.Name.Last = "Weaver"
+	if err := changed.ValidateUpdate(old); !apierrors.IsInvalid(err) || !strings.Contains(err.Error(), "spec.name.first") {
+		t.Errorf("ValidateUpdate() of a changed spec = %v, want spec.name.first invalid", err)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	user := &USER{Spec: USERSpec{Email: "  Janet.Weaver@ReqRes.in ", Name: UserName{First: " Janet "}, ImportID: " 2 "}}
+	user.Default()
+	if user.Spec.Email != "janet.weaver@reqres.in" || user.Spec.Name.First != "Janet" || user.Spec.ImportID != "2" {
+		t.Errorf("Default() left %+v", user.Spec)
+	}
+	if user.Spec.Avatar != "" {
+		t.Errorf("Default() avatar = %q, want it left to the create", user.Spec.Avatar)
+	}
+}
+
+func TestDefaultAvatar(t *testing.T) {
+	const gravatar = "https://gravatar.com/avatar/"
+	const teamAvatar = "https://reqres.in/img/faces/team-a.jpg"
+	for _, tc := range []struct {
+		name      string
+		operation admissionv1.Operation
+		namespace string
+		mutate    func(*USERSpec)
+		want      string
+	}{
+		{name: "create", operation: admissionv1.Create, namespace: "default", want: gravatar},
+		{name: "create with a namespace default", operation: admissionv1.Create, namespace: "team-a", want: teamAvatar},
+		{name: "create with an avatar", operation: admissionv1.Create, namespace: "team-a",
+			mutate: func(s *USERSpec) { s.Avatar = "https://example.com/janet.png" }, want: "https://example.com/janet.png"},
+		{name: "create with avatarFrom", operation: admissionv1.Create, namespace: "team-a",
+			mutate: func(s *USERSpec) { s.AvatarFrom = &AvatarSource{} }},
+		{name: "import", operation: admissionv1.Create, namespace: "team-a",
+			mutate: func(s *USERSpec) { s.ImportID = "2" }},
+		{name: "update", operation: admissionv1.Update, namespace: "team-a"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := clientgoscheme.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Annotations: map[string]string{DefaultAvatarAnnotation: teamAvatar}}},
+			).Build()
+			user := &USER{Spec: USERSpec{Email: "janet.weaver@reqres.in", Name: UserName{First: "Janet"}}}
+			if tc.mutate != nil {
+				tc.mutate(&user.Spec)
+			}
+			ctx := admission.NewContextWithRequest(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: tc.operation,
+				Namespace: tc.namespace,
+			}})
+			if err := (&namespaceDefaulter{reader: reader}).Default(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+			if tc.want == gravatar && !strings.HasPrefix(user.Spec.Avatar, gravatar) || tc.want != gravatar && user.Spec.Avatar != tc.want {
+				t.Errorf("avatar = %q, want %q", user.Spec.Avatar, tc.want)
+			}
+		})
+	}
+}
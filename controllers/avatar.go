@@ -0,0 +1,210 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	usersv1beta1 "github.com/adrafiq/reqres-controller/api/v1beta1"
+)
+
+// maxAvatarBytes caps spec.avatarFrom images, which travel base64 encoded in
+// every create and update and are read back on every sync.
+const maxAvatarBytes = 32 << 10
+
+// avatarSourceIndex indexes USERs by the "Kind/name" of the ConfigMap or
+// Secret their spec.avatarFrom reads.
+const avatarSourceIndex = "spec.avatarFrom"
+
+// desiredAvatar returns the avatar userCR asks for. Images from
+// spec.avatarFrom are returned as a data URL along with their hash.
+func (r *USERReconciler) desiredAvatar(ctx context.Context, userCR *usersv1beta1.USER) (avatar, hash string, err error) {
+	userKey := client.ObjectKeyFromObject(userCR)
+	source := userCR.Spec.AvatarFrom
+	if source == nil {
+		r.avatars.forget(userKey)
+		return userCR.Spec.Avatar, "", nil
+	}
+	var kind, name, key string
+	switch {
+	case source.ConfigMapKeyRef != nil:
+		kind, name, key = "ConfigMap", source.ConfigMapKeyRef.Name, source.ConfigMapKeyRef.Key
+	case source.SecretKeyRef != nil:
+		kind, name, key = "Secret", source.SecretKeyRef.Name, source.SecretKeyRef.Key
+	default:
+		r.avatars.forget(userKey)
+		return "", "", errors.New("avatarFrom: neither configMapKeyRef nor secretKeyRef is set")
+	}
+	cached, err := r.resolveAvatar(ctx, userKey, kind, types.NamespacedName{Namespace: userCR.Namespace, Name: name}, key)
+	if err != nil {
+		r.avatars.forget(userKey)
+		return "", "", err
+	}
+	return cached.avatar, cached.hash, nil
+}
+
+// resolveAvatar reads the image under key of a ConfigMap or Secret of kind.
+// With Metadata, the image is kept for the USER userKey and only read again
+// once the watched resource version of its source changed.
+func (r *USERReconciler) resolveAvatar(ctx context.Context, userKey types.NamespacedName, kind string, sourceKey types.NamespacedName, key string) (cachedAvatar, error) {
+	ref := kind + "/" + sourceKey.Name + "/" + key
+	if r.Metadata != nil {
+		current := &metav1.PartialObjectMetadata{}
+		current.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind(kind))
+		if err := r.Metadata.Get(ctx, sourceKey, current); err != nil {
+			return cachedAvatar{}, fmt.Errorf("avatarFrom: %s %s: %w", kind, sourceKey.Name, err)
+		}
+		if cached, ok := r.avatars.get(userKey); ok && cached.source == ref && cached.resourceVersion == current.ResourceVersion {
+			return cached, nil
+		}
+	}
+	var value []byte
+	var found bool
+	resolved := cachedAvatar{source: ref}
+	if kind == "ConfigMap" {
+		configMap := &corev1.ConfigMap{}
+		if err := r.Get(ctx, sourceKey, configMap); err != nil {
+			return cachedAvatar{}, fmt.Errorf("avatarFrom: %s %s: %w", kind, sourceKey.Name, err)
+		}
+		var text string
+		if text, found = configMap.Data[key]; found {
+			value = []byte(text)
+		} else {
+			value, found = configMap.BinaryData[key]
+		}
+		resolved.resourceVersion = configMap.ResourceVersion
+	} else {
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, sourceKey, secret); err != nil {
+			return cachedAvatar{}, fmt.Errorf("avatarFrom: %s %s: %w", kind, sourceKey.Name, err)
+		}
+		value, found = secret.Data[key]
+		resolved.resourceVersion = secret.ResourceVersion
+	}
+	if !found {
+		return cachedAvatar{}, fmt.Errorf("avatarFrom: %s %s has no key %s", kind, sourceKey.Name, key)
+	}
+	var err error
+	if resolved.avatar, resolved.hash, err = avatarDataURL(value); err != nil {
+		return cachedAvatar{}, err
+	}
+	if r.Metadata != nil {
+		r.avatars.set(userKey, resolved)
+	}
+	return resolved, nil
+}
+
+// avatarCache keeps the image each USER resolved from spec.avatarFrom. Only
+// the selected key of the ConfigMap or Secret is kept, never the rest of its
+// data. An entry is dropped once its USER is deleted or stops reading it.
+type avatarCache struct {
+	mu      sync.Mutex
+	avatars map[types.NamespacedName]cachedAvatar
+}
+
+type cachedAvatar struct {
+	// source is the "Kind/name/key" the image was read from, at
+	// resourceVersion.
+	source          string
+	resourceVersion string
+	avatar, hash    string
+}
+
+func (c *avatarCache) get(userKey types.NamespacedName) (cachedAvatar, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.avatars[userKey]
+	return cached, ok
+}
+
+func (c *avatarCache) set(userKey types.NamespacedName, cached cachedAvatar) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.avatars == nil {
+		c.avatars = map[types.NamespacedName]cachedAvatar{}
+	}
+	c.avatars[userKey] = cached
+}
+
+func (c *avatarCache) forget(userKey types.NamespacedName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.avatars, userKey)
+}
+
+// avatarDataURL inlines an image as a data URL and hashes it.
+func avatarDataURL(data []byte) (avatar, hash string, err error) {
+	if len(data) > maxAvatarBytes {
+		return "", "", fmt.Errorf("avatarFrom: image of %d bytes exceeds %d", len(data), maxAvatarBytes)
+	}
+	contentType := http.DetectContentType(data)
+	if !strings.HasPrefix(contentType, "image/") {
+		return "", "", fmt.Errorf("avatarFrom: content is %s, not an image", contentType)
+	}
+	sum := sha256.Sum256(data)
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data),
+		"sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// avatarSources returns the avatarSourceIndex keys of a USER.
+func avatarSources(obj client.Object) []string {
+	userCR, ok := obj.(*usersv1beta1.USER)
+	if !ok || userCR.Spec.AvatarFrom == nil {
+		return nil
+	}
+	switch source := userCR.Spec.AvatarFrom; {
+	case source.ConfigMapKeyRef != nil:
+		return []string{"ConfigMap/" + source.ConfigMapKeyRef.Name}
+	case source.SecretKeyRef != nil:
+		return []string{"Secret/" + source.SecretKeyRef.Name}
+	}
+	return nil
+}
+
+// usersForAvatarSource maps a ConfigMap or Secret of the given kind to the
+// USERs taking their avatar from it.
+func (r *USERReconciler) usersForAvatarSource(kind string) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		users := &usersv1beta1.USERList{}
+		if err := r.List(context.Background(), users,
+			client.InNamespace(obj.GetNamespace()),
+			client.MatchingFields{avatarSourceIndex: kind + "/" + obj.GetName()},
+		); err != nil {
+			return nil
+		}
+		requests := make([]reconcile.Request, 0, len(users.Items))
+		for _, user := range users.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&user)})
+		}
+		return requests
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	usersv1beta1 "github.com/adrafiq/reqres-controller/api/v1beta1"
+)
+
+// fakeBackend keeps a single user, applying creates and patches to it.
+type fakeBackend struct {
+	user    map[string]string
+	patches int
+}
+
+func (b *fakeBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost, http.MethodPatch:
+		body := map[string]string{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if r.Method == http.MethodPatch {
+			for attribute, value := range body {
+				b.user[attribute] = value
+			}
+			b.patches++
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		b.user = body
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"7"}`))
+	case http.MethodGet:
+		data, _ := json.Marshal(b.user)
+		_, _ = w.Write([]byte(`{"data":` + strings.Replace(string(data), "{", `{"id":7,`, 1) + `}`))
+	}
+}
+
+func TestAvatarIsSynced(t *testing.T) {
+	png := func(payload string) []byte { return []byte("\x89PNG\r\n\x1a\n" + payload) }
+	users := &fakeBackend{}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "avatars", Namespace: "default"},
+		Data:       map[string]string{"api-key": "unrelated"},
+		BinaryData: map[string][]byte{"janet.png": png("first")},
+	}
+	user := newTestUser()
+	user.Spec.Avatar = "https://reqres.in/img/faces/2-image.jpg"
+	user.Spec.AvatarFrom = &usersv1beta1.AvatarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "avatars"},
+		Key:                  "janet.png",
+	}}
+	r := newTestReconciler(t, users, user, configMap)
+	k8sClient := r.Client
+	reads := &getCounter{Client: k8sClient, kind: &corev1.ConfigMap{}}
+	r.Client, r.Metadata = reads, k8sClient
+	reconcile := func() *usersv1beta1.USER {
+		t.Helper()
+		return reconcileUser(t, r, client.ObjectKeyFromObject(user))
+	}
+
+	// Created with the image, which takes precedence over spec.avatar
+	first := reconcile()
+	want, firstHash, _ := avatarDataURL(png("first"))
+	if users.user["avatar"] != want {
+		t.Fatalf("created with avatar %q, want %q", users.user["avatar"], want)
+	}
+	if first.Status.AvatarHash != firstHash {
+		t.Errorf("avatarHash = %q, want %q", first.Status.AvatarHash, firstHash)
+	}
+	// Only the selected image is kept, not the rest of the ConfigMap
+	key := client.ObjectKeyFromObject(user)
+	if cached, ok := r.avatars.get(key); !ok || cached.source != "ConfigMap/avatars/janet.png" || cached.avatar != want {
+		t.Errorf("cached avatar = %+v, want the image of janet.png", cached)
+	}
+
+	// An unchanged avatar is not drift, and its ConfigMap is not read again
+	reconcile()
+	if users.patches != 0 {
+		t.Errorf("unchanged avatar was patched %d times", users.patches)
+	}
+	if reads.gets != 1 {
+		t.Errorf("unchanged ConfigMap was read %d times, want once", reads.gets)
+	}
+
+	// A new image is uploaded right away
+	configMap.BinaryData["janet.png"] = png("second")
+	if err := k8sClient.Update(context.Background(), configMap); err != nil {
+		t.Fatal(err)
+	}
+	second := reconcile()
+	want, secondHash, _ := avatarDataURL(png("second"))
+	if users.patches != 1 || users.user["avatar"] != want {
+		t.Errorf("after %d patches backend avatar is %q, want %q", users.patches, users.user["avatar"], want)
+	}
+	if second.Status.AvatarHash != secondHash || second.Status.AtProvider.Avatar != "" {
+		t.Errorf("status does not track the new image: %+v", second.Status)
+	}
+	if reads.gets != 2 {
+		t.Errorf("ConfigMap was read %d times, want again once changed", reads.gets)
+	}
+
+	// Images too large to inline are refused
+	if _, _, err := avatarDataURL(png(strings.Repeat("x", maxAvatarBytes))); err == nil {
+		t.Error("avatarDataURL() accepted an image over maxAvatarBytes")
+	}
+
+	// Without avatarFrom, spec.avatar is synced and the hash dropped
+	second.Spec.AvatarFrom = nil
+	second.Generation++
+	if err := k8sClient.Update(context.Background(), second); err != nil {
+		t.Fatal(err)
+	}
+	third := reconcile()
+	if users.user["avatar"] != user.Spec.Avatar || third.Status.AvatarHash != "" {
+		t.Errorf("backend avatar %q and hash %q after switching to spec.avatar", users.user["avatar"], third.Status.AvatarHash)
+	}
+	if _, ok := r.avatars.get(key); ok {
+		t.Error("cached avatar was kept after switching to spec.avatar")
+	}
+
+	// Deleted users drop their cached avatar
+	r.avatars.set(key, cachedAvatar{source: "ConfigMap/avatars/janet.png"})
+	if err := k8sClient.Delete(context.Background(), third); err != nil {
+		t.Fatal(err)
+	}
+	reconcile()
+	if _, ok := r.avatars.get(key); ok {
+		t.Error("cached avatar was kept after the user was deleted")
+	}
+}
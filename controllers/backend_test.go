@@ -0,0 +1,158 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	usersv1beta1 "github.com/adrafiq/reqres-controller/api/v1beta1"
+	"github.com/adrafiq/reqres-controller/pkg/backend"
+)
+
+func TestUserSelectsBackend(t *testing.T) {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "identity",
+		Annotations: map[string]string{usersv1beta1.BackendAnnotation: "identity"},
+	}}
+	newUser := func(name, selected string) *usersv1beta1.USER {
+		user := &usersv1beta1.USER{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "identity"},
+			Spec: usersv1beta1.USERSpec{
+				Email: name + "@reqres.in",
+				Name:  usersv1beta1.UserName{First: name},
+			},
+		}
+		if selected != "" {
+			user.Annotations = map[string]string{usersv1beta1.BackendAnnotation: selected}
+		}
+		return user
+	}
+	r := newTestReconciler(t, nil,
+		namespace,
+		newUser("janet", ""),
+		newUser("emma", "memory"),
+		newUser("eve", "ldap"),
+	)
+	memory, identity := backend.NewMemory(), backend.NewMemory()
+	r.Backends = backend.NewRegistry("memory", memory)
+	r.Backends.Register("identity", identity)
+	k8sClient := r.Client
+	reads := &getCounter{Client: k8sClient, kind: &metav1.PartialObjectMetadata{}}
+	r.Client, r.Metadata = reads, k8sClient
+	reconcile := func(name string) *usersv1beta1.USER {
+		t.Helper()
+		return reconcileUser(t, r, types.NamespacedName{Name: name, Namespace: "identity"})
+	}
+
+	// The namespace, as cached, selects for users without their own choice
+	janet := reconcile("janet")
+	if janet.Status.Backend != "identity" {
+		t.Errorf("janet is managed in %q, want identity", janet.Status.Backend)
+	}
+	if reads.gets != 0 {
+		t.Errorf("namespace was read %d times around the cache", reads.gets)
+	}
+	if _, err := identity.GetUser(context.Background(), *janet.Status.ExternalID); err != nil {
+		t.Errorf("janet is not in the identity backend: %v", err)
+	}
+
+	// The user's own annotation wins
+	if emma := reconcile("emma"); emma.Status.Backend != "memory" {
+		t.Errorf("emma is managed in %q, want memory", emma.Status.Backend)
+	}
+	if page, _ := memory.ListUsers(context.Background(), 1, 0); page.Total != 1 {
+		t.Errorf("memory backend holds %d users, want 1", page.Total)
+	}
+
+	// Unregistered backends are reported
+	eve := reconcile("eve")
+	synced := meta.FindStatusCondition(eve.Status.Conditions, usersv1beta1.ConditionSynced)
+	if eve.Status.Created() || synced == nil || synced.Reason != usersv1beta1.ReasonUnknownBackend {
+		t.Errorf("eve selecting an unknown backend: %+v", eve.Status)
+	}
+
+	// Created users stay where they are
+	janet.Annotations = map[string]string{usersv1beta1.BackendAnnotation: "memory"}
+	if err := k8sClient.Update(context.Background(), janet); err != nil {
+		t.Fatal(err)
+	}
+	if janet = reconcile("janet"); janet.Status.Backend != "identity" {
+		t.Errorf("janet moved to %q", janet.Status.Backend)
+	}
+}
+
+func TestUserWithoutBackendIsDeleted(t *testing.T) {
+	id := "7"
+	for _, tc := range []struct {
+		name   string
+		mutate func(*usersv1beta1.USER)
+		kept   bool
+	}{{
+		name:   "orphaned",
+		mutate: func(user *usersv1beta1.USER) { user.Spec.DeletionPolicy = usersv1beta1.DeletionPolicyOrphan },
+	}, {
+		name:   "observed only",
+		mutate: func(user *usersv1beta1.USER) { user.Spec.ManagementPolicy = usersv1beta1.ManagementPolicyObserveOnly },
+	}, {
+		name:   "never created",
+		mutate: func(user *usersv1beta1.USER) { user.Status.ExternalID = nil },
+	}, {
+		name: "interrupted create",
+		mutate: func(user *usersv1beta1.USER) {
+			user.Status.ExternalID = nil
+			user.Annotations = map[string]string{createIntentAnnotation: "key"}
+		},
+	}, {
+		name:   "deleting the backend user",
+		mutate: func(*usersv1beta1.USER) {},
+		kept:   true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			now := metav1.Now()
+			user := newTestUser()
+			user.DeletionTimestamp = &now
+			user.Finalizers = []string{ctrlFinalizer}
+			user.Spec.BackendRef = &usersv1beta1.ReqresBackendReference{Name: "gone"}
+			user.Status.ExternalID = &id
+			user.Status.Backend = reqresBackendPrefix + "gone"
+			tc.mutate(user)
+			r := newTestReconciler(t, nil, user)
+			r.ReqresClients = NewReqresClients(nil)
+
+			got := reconcileUser(t, r, types.NamespacedName{Name: "janet", Namespace: "default"})
+			if !tc.kept {
+				if got != nil {
+					t.Errorf("USER was not deleted, finalizers %v", got.Finalizers)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("USER was deleted without deleting its backend user")
+			}
+			synced := meta.FindStatusCondition(got.Status.Conditions, usersv1beta1.ConditionSynced)
+			if synced == nil || synced.Reason != usersv1beta1.ReasonUnknownBackend {
+				t.Errorf("Synced = %+v, want reason %s", synced, usersv1beta1.ReasonUnknownBackend)
+			}
+		})
+	}
+}
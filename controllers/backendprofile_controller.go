@@ -0,0 +1,194 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	usersv1beta1 "github.com/adrafiq/reqres-controller/api/v1beta1"
+	"github.com/adrafiq/reqres-controller/pkg/backend"
+	reqres "github.com/adrafiq/reqres-controller/pkg/reqres"
+)
+
+// BackendProfileReconciler registers every valid BackendProfile as a
+// backend under its name, so that USERs select it like any other.
+type BackendProfileReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Backends *backend.Registry
+	// NewClient returns the client a profile's requests are sent with, one
+	// per profile.
+	NewClient func() *reqres.Client
+
+	mu sync.Mutex
+	// registered maps the profiles in Backends to the generation they were
+	// compiled at. Other names in Backends belong to built-in backends.
+	registered map[string]int64
+}
+
+//+kubebuilder:rbac:groups=users.reqres.in,resources=backendprofiles,verbs=get;list;watch
+//+kubebuilder:rbac:groups=users.reqres.in,resources=backendprofiles/status,verbs=get;update;patch
+
+// Reconcile compiles the profile and (re)registers it, reporting the outcome
+// in its Ready condition. Deleted profiles are unregistered.
+func (r *BackendProfileReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	profile := &usersv1beta1.BackendProfile{}
+	if err := r.Get(ctx, req.NamespacedName, profile); err != nil {
+		if errors.IsNotFound(err) {
+			if r.unregister(req.Name) {
+				logger.Info("unregistered backend profile")
+			}
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	base := profile.Status.DeepCopy()
+	profile.Status.ObservedGeneration = profile.Generation
+	switch err := r.register(profile); {
+	case err == errNameTaken:
+		setProfileCondition(profile, metav1.ConditionFalse, usersv1beta1.ReasonNameTaken,
+			fmt.Sprintf("a built-in backend is named %s", profile.Name))
+	case err != nil:
+		logger.Info("invalid backend profile", "error", err.Error())
+		setProfileCondition(profile, metav1.ConditionFalse, usersv1beta1.ReasonInvalidProfile, err.Error())
+	default:
+		setProfileCondition(profile, metav1.ConditionTrue, usersv1beta1.ReasonProfileRegistered,
+			fmt.Sprintf("USERs select this profile with the %s annotation", usersv1beta1.BackendAnnotation))
+	}
+	if equality.Semantic.DeepEqual(*base, profile.Status) {
+		return ctrl.Result{}, nil
+	}
+	original := profile.DeepCopy()
+	original.Status = *base
+	return ctrl.Result{}, client.IgnoreNotFound(r.Status().Patch(ctx, profile, client.MergeFrom(original)))
+}
+
+// errNameTaken is returned by register for a profile named like a built-in
+// backend, which it must not replace.
+var errNameTaken = goerrors.New("name taken by a built-in backend")
+
+// register compiles profile into a backend, unless the generation
+// registered already is current. An invalid profile is unregistered, so
+// that USERs stop using a profile they no longer find in its last version.
+func (r *BackendProfileReconciler) register(profile *usersv1beta1.BackendProfile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.registered == nil {
+		r.registered = map[string]int64{}
+	}
+	generation, ours := r.registered[profile.Name]
+	if !ours {
+		if _, err := r.Backends.Get(profile.Name); err == nil {
+			return errNameTaken
+		}
+	} else if generation == profile.Generation {
+		return nil
+	}
+	profileClient := r.NewClient()
+	compiled, err := reqres.NewProfileClient(profileOf(profile.Spec), profileClient)
+	if err != nil {
+		delete(r.registered, profile.Name)
+		r.Backends.Unregister(profile.Name)
+		reqres.ForgetBreaker(profile.Name, "")
+		return err
+	}
+	if profileClient.Breaker != nil {
+		profileClient.Breaker.Report(profile.Name, "")
+	}
+	r.registered[profile.Name] = profile.Generation
+	r.Backends.Register(profile.Name, compiled)
+	return nil
+}
+
+// unregister removes the profile registered as name and reports whether
+// there was one.
+func (r *BackendProfileReconciler) unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ours := r.registered[name]; !ours {
+		return false
+	}
+	delete(r.registered, name)
+	r.Backends.Unregister(name)
+	reqres.ForgetBreaker(name, "")
+	return true
+}
+
+// profileOf converts the spec of a BackendProfile for the reqres package.
+func profileOf(spec usersv1beta1.BackendProfileSpec) reqres.Profile {
+	operations := spec.Operations
+	profile := reqres.Profile{
+		BaseURL:                spec.BaseURL,
+		Attributes:             spec.Attributes,
+		Headers:                spec.Headers,
+		SupportsIdempotencyKey: spec.SupportsIdempotencyKey,
+		Create:                 profileOperationOf(operations.Create),
+		Get:                    profileOperationOf(operations.Get),
+		Update:                 profileOperationOf(operations.Update),
+		Delete:                 profileOperationOf(operations.Delete),
+	}
+	if operations.List != nil {
+		list := profileOperationOf(*operations.List)
+		profile.List = &list
+	}
+	return profile
+}
+
+func profileOperationOf(operation usersv1beta1.BackendOperation) reqres.ProfileOperation {
+	return reqres.ProfileOperation{
+		Method:       operation.Method,
+		Path:         operation.Path,
+		Body:         operation.Body,
+		SuccessCodes: operation.SuccessCodes,
+		User:         operation.Response.User,
+		ID:           operation.Response.ID,
+		Items:        operation.Response.Items,
+		TotalPages:   operation.Response.TotalPages,
+	}
+}
+
+func setProfileCondition(profile *usersv1beta1.BackendProfile, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&profile.Status.Conditions, metav1.Condition{
+		Type:               usersv1beta1.ConditionReady,
+		Status:             status,
+		ObservedGeneration: profile.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *BackendProfileReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&usersv1beta1.BackendProfile{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	usersv1beta1 "github.com/adrafiq/reqres-controller/api/v1beta1"
+	"github.com/adrafiq/reqres-controller/pkg/backend"
+	reqres "github.com/adrafiq/reqres-controller/pkg/reqres"
+)
+
+func TestBackendProfileIsRegistered(t *testing.T) {
+	scheme := newTestScheme(t)
+	operation := func(method, path string, code int) usersv1beta1.BackendOperation {
+		return usersv1beta1.BackendOperation{Method: method, Path: path, SuccessCodes: []int{code}}
+	}
+	newProfile := func(name string) *usersv1beta1.BackendProfile {
+		return &usersv1beta1.BackendProfile{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Generation: 1},
+			Spec: usersv1beta1.BackendProfileSpec{
+				BaseURL:    "https://api.example.com",
+				Attributes: map[string]string{reqres.FieldEmail: "email", reqres.FieldFirstName: "first_name"},
+				Operations: usersv1beta1.BackendOperations{
+					Create: operation(http.MethodPost, "/users", http.StatusCreated),
+					Get:    operation(http.MethodGet, "/users/{{ .ID }}", http.StatusOK),
+					Update: operation(http.MethodPatch, "/users/{{ .ID }}", http.StatusOK),
+					Delete: operation(http.MethodDelete, "/users/{{ .ID }}", http.StatusNoContent),
+				},
+			},
+		}
+	}
+	invalid := newProfile("invalid")
+	invalid.Spec.Operations.Get.Path = "/users/{{ .ID"
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newProfile("directory"),
+		newProfile("reqres"),
+		invalid,
+	).Build()
+	reqresClient := reqres.NewClient("https://reqres.in", nil)
+	backends := backend.NewRegistry("reqres", &reqresClient)
+	r := &BackendProfileReconciler{
+		Client:   k8sClient,
+		Scheme:   scheme,
+		Backends: backends,
+		NewClient: func() *reqres.Client {
+			client := reqres.NewClient("", nil)
+			return &client
+		},
+	}
+	reconcile := func(name string) *metav1.Condition {
+		t.Helper()
+		if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: name}}); err != nil {
+			t.Fatal(err)
+		}
+		profile := &usersv1beta1.BackendProfile{}
+		if err := k8sClient.Get(context.Background(), types.NamespacedName{Name: name}, profile); err != nil {
+			return nil
+		}
+		return meta.FindStatusCondition(profile.Status.Conditions, usersv1beta1.ConditionReady)
+	}
+
+	for name, reason := range map[string]string{
+		"directory": usersv1beta1.ReasonProfileRegistered,
+		"reqres":    usersv1beta1.ReasonNameTaken,
+		"invalid":   usersv1beta1.ReasonInvalidProfile,
+	} {
+		if ready := reconcile(name); ready == nil || ready.Reason != reason || ready.ObservedGeneration != 1 {
+			t.Errorf("%s: Ready = %+v, want reason %s", name, ready, reason)
+		}
+	}
+	if got, err := backends.Get("directory"); err != nil {
+		t.Errorf("directory is not registered: %v", err)
+	} else if _, ok := got.(*reqres.ProfileClient); !ok {
+		t.Errorf("directory is registered as %T", got)
+	}
+	if got, _ := backends.Get("reqres"); got != &reqresClient {
+		t.Error("a profile replaced the reqres backend")
+	}
+	if _, err := backends.Get("invalid"); !errors.Is(err, backend.ErrUnknownBackend) {
+		t.Errorf("invalid profile is registered: %v", err)
+	}
+
+	if err := k8sClient.Delete(context.Background(), newProfile("directory")); err != nil {
+		t.Fatal(err)
+	}
+	reconcile("directory")
+	if _, err := backends.Get("directory"); !errors.Is(err, backend.ErrUnknownBackend) {
+		t.Errorf("deleted profile is still registered: %v", err)
+	}
+}
+
+func TestUserWaitsForBackendProfile(t *testing.T) {
+	profile := &usersv1beta1.BackendProfile{ObjectMeta: metav1.ObjectMeta{Name: "directory"}}
+	user := newTestUser()
+	user.Annotations = map[string]string{usersv1beta1.BackendAnnotation: "directory"}
+	r := newTestReconciler(t, nil, profile, user)
+	recorder := record.NewFakeRecorder(10)
+	r.Recorder = recorder
+	key := types.NamespacedName{Name: "janet", Namespace: "default"}
+
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+	if err != nil || !result.Requeue {
+		t.Fatalf("Reconcile() = %+v, %v, want a requeue with backoff", result, err)
+	}
+	got := &usersv1beta1.USER{}
+	if err := r.Get(context.Background(), key, got); err != nil {
+		t.Fatal(err)
+	}
+	synced := meta.FindStatusCondition(got.Status.Conditions, usersv1beta1.ConditionSynced)
+	if synced == nil || synced.Reason != usersv1beta1.ReasonBackendNotReady {
+		t.Errorf("Synced = %+v, want reason %s", synced, usersv1beta1.ReasonBackendNotReady)
+	}
+	if len(recorder.Events) != 0 {
+		t.Errorf("recorded %q for a profile about to be registered", <-recorder.Events)
+	}
+
+	r.Backends.Register("directory", backend.NewMemory())
+	if got = reconcileUser(t, r, key); !got.Status.Created() || got.Status.Backend != "directory" {
+		t.Errorf("status = %+v, want the user created in directory", got.Status)
+	}
+}
+
+func TestSelectedBackends(t *testing.T) {
+	id := "7"
+	for _, tc := range []struct {
+		name   string
+		mutate func(*usersv1beta1.USER)
+		want   []string
+	}{{
+		name:   "namespace or default",
+		mutate: func(*usersv1beta1.USER) {},
+		want:   []string{""},
+	}, {
+		name: "annotation",
+		mutate: func(user *usersv1beta1.USER) {
+			user.Annotations = map[string]string{usersv1beta1.BackendAnnotation: "directory"}
+		},
+		want: []string{"directory"},
+	}, {
+		name: "backendRef",
+		mutate: func(user *usersv1beta1.USER) {
+			user.Spec.BackendRef = &usersv1beta1.ReqresBackendReference{Name: "tenant"}
+		},
+	}, {
+		name: "created",
+		mutate: func(user *usersv1beta1.USER) {
+			user.Annotations = map[string]string{usersv1beta1.BackendAnnotation: "other"}
+			user.Status.ExternalID = &id
+			user.Status.Backend = "directory"
+		},
+		want: []string{"directory"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			user := newTestUser()
+			tc.mutate(user)
+			if got := selectedBackends(user); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("selectedBackends() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
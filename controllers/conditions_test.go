@@ -0,0 +1,192 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	usersv1beta1 "github.com/adrafiq/reqres-controller/api/v1beta1"
+	reqres "github.com/adrafiq/reqres-controller/pkg/reqres"
+)
+
+func TestReconcileSetsConditions(t *testing.T) {
+	const janet = `{"data":{"id":7,"email":"janet.weaver@reqres.in","first_name":"Janet","last_name":"Weaver"}}`
+	readySince := metav1.NewTime(time.Date(2022, 11, 20, 10, 0, 0, 0, time.UTC))
+
+	for _, tc := range []struct {
+		name         string
+		status       int
+		body         string
+		ready        metav1.ConditionStatus
+		readyReason  string
+		synced       metav1.ConditionStatus
+		syncedReason string
+		created      bool
+	}{{
+		name:         "in sync",
+		status:       http.StatusOK,
+		body:         janet,
+		ready:        metav1.ConditionTrue,
+		readyReason:  usersv1beta1.ReasonAvailable,
+		synced:       metav1.ConditionTrue,
+		syncedReason: usersv1beta1.ReasonReconcileSuccess,
+		created:      true,
+	}, {
+		name:         "backend user gone",
+		status:       http.StatusNotFound,
+		ready:        metav1.ConditionFalse,
+		readyReason:  usersv1beta1.ReasonBackendNotFound,
+		synced:       metav1.ConditionFalse,
+		syncedReason: usersv1beta1.ReasonBackendNotFound,
+	}, {
+		name:         "backend failing",
+		status:       http.StatusServiceUnavailable,
+		ready:        metav1.ConditionTrue,
+		readyReason:  usersv1beta1.ReasonAvailable,
+		synced:       metav1.ConditionFalse,
+		syncedReason: usersv1beta1.ReasonBackendError,
+		created:      true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			id := "7"
+			user := newTestUser()
+			user.Generation = 3
+			user.Finalizers = []string{ctrlFinalizer}
+			user.Status = usersv1beta1.USERStatus{
+				ExternalID:         &id,
+				ObservedGeneration: 2,
+				Conditions: []metav1.Condition{{
+					Type:               usersv1beta1.ConditionReady,
+					Status:             metav1.ConditionTrue,
+					ObservedGeneration: 2,
+					LastTransitionTime: readySince,
+					Reason:             usersv1beta1.ReasonAvailable,
+				}},
+			}
+			r := newTestReconciler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}), user)
+
+			got := reconcileUser(t, r, client.ObjectKeyFromObject(user))
+			if got.Status.ObservedGeneration != 3 {
+				t.Errorf("observedGeneration = %d, want 3", got.Status.ObservedGeneration)
+			}
+			if got.Status.Created() != tc.created {
+				t.Errorf("created = %v, want %v", got.Status.Created(), tc.created)
+			}
+			ready := meta.FindStatusCondition(got.Status.Conditions, usersv1beta1.ConditionReady)
+			if ready == nil || ready.Status != tc.ready || ready.Reason != tc.readyReason {
+				t.Fatalf("Ready = %+v, want %s/%s", ready, tc.ready, tc.readyReason)
+			}
+			if ready.Status == metav1.ConditionTrue && !ready.LastTransitionTime.Equal(&readySince) {
+				t.Errorf("Ready transition time moved to %v without a status change", ready.LastTransitionTime)
+			}
+			synced := meta.FindStatusCondition(got.Status.Conditions, usersv1beta1.ConditionSynced)
+			if synced == nil || synced.Status != tc.synced || synced.Reason != tc.syncedReason {
+				t.Fatalf("Synced = %+v, want %s/%s", synced, tc.synced, tc.syncedReason)
+			}
+			if synced.ObservedGeneration != 3 {
+				t.Errorf("Synced observedGeneration = %d, want 3", synced.ObservedGeneration)
+			}
+		})
+	}
+}
+
+func TestCircuitOpenSetsBackendAvailable(t *testing.T) {
+	const janet = `{"data":{"id":7,"email":"janet.weaver@reqres.in","first_name":"Janet","last_name":"Weaver"}}`
+	id := "7"
+	user := newTestUser()
+	user.Finalizers = []string{ctrlFinalizer}
+	user.Status.ExternalID = &id
+	status := http.StatusServiceUnavailable
+	r := newTestReconciler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(janet))
+	}), user)
+	userBackend, err := r.Backends.Get("reqres")
+	if err != nil {
+		t.Fatal(err)
+	}
+	breaker := reqres.NewCircuitBreaker(1, 50*time.Millisecond)
+	userBackend.(*reqres.Client).Breaker = breaker
+	conditions := func(got *usersv1beta1.USER) (synced, available *metav1.Condition) {
+		return meta.FindStatusCondition(got.Status.Conditions, usersv1beta1.ConditionSynced),
+			meta.FindStatusCondition(got.Status.Conditions, usersv1beta1.ConditionBackendAvailable)
+	}
+
+	// The failure opens the circuit, which the next reconcile reports
+	reconcileUser(t, r, client.ObjectKeyFromObject(user))
+	synced, available := conditions(reconcileUser(t, r, client.ObjectKeyFromObject(user)))
+	if synced == nil || synced.Reason != usersv1beta1.ReasonCircuitOpen {
+		t.Fatalf("Synced = %+v, want CircuitOpen", synced)
+	}
+	if available == nil || available.Status != metav1.ConditionFalse || available.Reason != usersv1beta1.ReasonCircuitOpen {
+		t.Fatalf("BackendAvailable = %+v, want False/CircuitOpen", available)
+	}
+
+	// The probe after the cool-down succeeds
+	status = http.StatusOK
+	time.Sleep(breaker.CoolDown)
+	synced, available = conditions(reconcileUser(t, r, client.ObjectKeyFromObject(user)))
+	if synced == nil || synced.Status != metav1.ConditionTrue {
+		t.Fatalf("Synced = %+v, want True", synced)
+	}
+	if available == nil || available.Status != metav1.ConditionTrue {
+		t.Errorf("BackendAvailable = %+v, want True", available)
+	}
+}
+
+func TestLegacyConditionsAreRemoved(t *testing.T) {
+	const janet = `{"data":{"id":7,"email":"janet.weaver@reqres.in","first_name":"Janet","last_name":"Weaver"}}`
+	id := "7"
+	user := newTestUser()
+	user.Finalizers = []string{ctrlFinalizer}
+	user.Status = usersv1beta1.USERStatus{
+		ExternalID: &id,
+		Conditions: []metav1.Condition{{
+			Type:               "Available",
+			Status:             metav1.ConditionTrue,
+			LastTransitionTime: metav1.NewTime(time.Date(2022, 11, 20, 10, 0, 0, 0, time.UTC)),
+			Reason:             "OK",
+		}, {
+			Type:               "Unavailable",
+			Status:             metav1.ConditionUnknown,
+			LastTransitionTime: metav1.NewTime(time.Date(2022, 11, 19, 10, 0, 0, 0, time.UTC)),
+			Reason:             "Failed",
+		}},
+	}
+	r := newTestReconciler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(janet))
+	}), user)
+
+	got := reconcileUser(t, r, client.ObjectKeyFromObject(user))
+	for _, condition := range got.Status.Conditions {
+		if condition.Type == "Available" || condition.Type == "Unavailable" {
+			t.Errorf("legacy condition %+v was kept", condition)
+		}
+	}
+	if meta.FindStatusCondition(got.Status.Conditions, usersv1beta1.ConditionReady) == nil {
+		t.Error("Ready was not set")
+	}
+}
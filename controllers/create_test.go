@@ -0,0 +1,105 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	usersv1beta1 "github.com/adrafiq/reqres-controller/api/v1beta1"
+
+	"github.com/adrafiq/reqres-controller/pkg/backend"
+	reqres "github.com/adrafiq/reqres-controller/pkg/reqres"
+)
+
+func TestCreateResumesFromIntent(t *testing.T) {
+	ctx := context.Background()
+	emma := reqres.User{Email: "emma.wong@reqres.in", FirstName: "Emma"}
+	janet := reqres.User{Email: "Janet.Weaver@reqres.in", FirstName: "Janet", LastName: "Weaver"}
+	for _, tc := range []struct {
+		name     string
+		existing []reqres.User
+		intent   string
+		wantID   string
+		creates  bool
+	}{{
+		name:     "first attempt",
+		existing: []reqres.User{emma},
+		wantID:   "2",
+		creates:  true,
+	}, {
+		name:     "interrupted before the backend created the user",
+		existing: []reqres.User{emma},
+		intent:   "key-1",
+		wantID:   "2",
+		creates:  true,
+	}, {
+		name:     "interrupted before the id was recorded",
+		existing: []reqres.User{emma, janet},
+		intent:   "key-1",
+		wantID:   "2",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			memory := backend.NewMemory()
+			for _, user := range tc.existing {
+				if _, err := memory.CreateUser(ctx, user, ""); err != nil {
+					t.Fatal(err)
+				}
+			}
+			user := newTestUser()
+			if tc.intent != "" {
+				user.Annotations = map[string]string{createIntentAnnotation: tc.intent}
+			}
+			r := newTestReconciler(t, nil, user)
+			r.Backends = backend.NewRegistry("memory", memory)
+
+			got := reconcileUser(t, r, client.ObjectKeyFromObject(user))
+			if !got.Status.Created() || *got.Status.ExternalID != tc.wantID {
+				t.Fatalf("status = %+v, want id %s", got.Status, tc.wantID)
+			}
+			if page, _ := memory.ListUsers(ctx, 1, 0); page.Total != 2 {
+				t.Errorf("backend holds %d users, want 2", page.Total)
+			}
+			if _, ok := got.Annotations[createIntentAnnotation]; ok {
+				t.Error("create intent was kept after the id was recorded")
+			}
+			if tc.intent != "" && tc.creates {
+				// The create carried the intent's key, which dedupes a repeat
+				if again, _ := memory.CreateUser(ctx, janet, tc.intent); again.Id != tc.wantID {
+					t.Errorf("create was not sent with the intent's key, a repeat created %s", again.Id)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateObservesTheSentUser(t *testing.T) {
+	user := newTestUser()
+	r := newTestReconciler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"7","createdAt":"2022-11-20T10:00:00.000Z"}`))
+	}), user)
+
+	got := reconcileUser(t, r, client.ObjectKeyFromObject(user))
+	want := usersv1beta1.USERObservation{Email: user.Spec.Email, Name: user.Spec.Name}
+	if got.Status.AtProvider == nil || *got.Status.AtProvider != want {
+		t.Errorf("atProvider = %+v, want %+v", got.Status.AtProvider, want)
+	}
+}
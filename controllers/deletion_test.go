@@ -0,0 +1,99 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	usersv1beta1 "github.com/adrafiq/reqres-controller/api/v1beta1"
+	"github.com/adrafiq/reqres-controller/pkg/backend"
+	reqres "github.com/adrafiq/reqres-controller/pkg/reqres"
+)
+
+func TestDeletionFollowsPolicies(t *testing.T) {
+	ctx := context.Background()
+	deletionPolicies := []usersv1beta1.DeletionPolicy{"", usersv1beta1.DeletionPolicyDelete, usersv1beta1.DeletionPolicyOrphan, usersv1beta1.DeletionPolicyRetain}
+	managementPolicies := []usersv1beta1.ManagementPolicy{"", usersv1beta1.ManagementPolicyFull, usersv1beta1.ManagementPolicyObserveOnly, usersv1beta1.ManagementPolicyCreateOnly}
+	for _, deletionPolicy := range deletionPolicies {
+		for _, managementPolicy := range managementPolicies {
+			deletes := (deletionPolicy == "" || deletionPolicy == usersv1beta1.DeletionPolicyDelete) &&
+				(managementPolicy == "" || managementPolicy == usersv1beta1.ManagementPolicyFull)
+			t.Run(fmt.Sprintf("%q/%q", deletionPolicy, managementPolicy), func(t *testing.T) {
+				memory := backend.NewMemory()
+				created, err := memory.CreateUser(ctx, reqres.User{Email: "janet.weaver@reqres.in", FirstName: "Janet"}, "")
+				if err != nil {
+					t.Fatal(err)
+				}
+				now := metav1.Now()
+				user := newTestUser()
+				user.DeletionTimestamp = &now
+				user.Finalizers = []string{ctrlFinalizer}
+				user.Spec.DeletionPolicy = deletionPolicy
+				user.Spec.ManagementPolicy = managementPolicy
+				user.Status.ExternalID = &created.Id
+				user.Status.Backend = "memory"
+				r := newTestReconciler(t, nil, user)
+				r.Backends = backend.NewRegistry("memory", memory)
+
+				if got := reconcileUser(t, r, client.ObjectKeyFromObject(user)); got != nil {
+					t.Errorf("USER was not deleted, finalizers %v", got.Finalizers)
+				}
+				_, err = memory.GetUser(ctx, created.Id)
+				if gone := goerrors.Is(err, reqres.ErrNotFound); gone != deletes {
+					t.Errorf("backend user deleted = %v, want %v", gone, deletes)
+				}
+			})
+		}
+	}
+}
+
+func TestFailedDeletionKeepsFinalizer(t *testing.T) {
+	id := "7"
+	now := metav1.Now()
+	user := newTestUser()
+	user.DeletionTimestamp = &now
+	user.Finalizers = []string{ctrlFinalizer}
+	user.Status.ExternalID = &id
+	user.Status.Backend = "reqres"
+	r := newTestReconciler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}), user)
+
+	key := client.ObjectKeyFromObject(user)
+	_, _ = r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+	got := &usersv1beta1.USER{}
+	if err := r.Get(context.Background(), key, got); err != nil {
+		t.Fatalf("USER was deleted although its backend user was not: %v", err)
+	}
+	if !controllerutil.ContainsFinalizer(got, ctrlFinalizer) {
+		t.Errorf("finalizer was removed, finalizers %v", got.Finalizers)
+	}
+	deleting := meta.FindStatusCondition(got.Status.Conditions, usersv1beta1.ConditionDeleting)
+	if deleting == nil || deleting.Reason != usersv1beta1.ReasonDeletingBackend {
+		t.Errorf("Deleting = %+v, want reason %s", deleting, usersv1beta1.ReasonDeletingBackend)
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	usersv1beta1 "github.com/adrafiq/reqres-controller/api/v1beta1"
+)
+
+// driftedBackend serves janet with her first name changed to Jane, creates
+// her with id 7, and counts the requests it receives.
+type driftedBackend struct {
+	gets, posts, patches int
+}
+
+func (b *driftedBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		b.gets++
+		_, _ = w.Write([]byte(`{"data":{"id":7,"email":"janet.weaver@reqres.in","first_name":"Jane","last_name":"Weaver"}}`))
+	case http.MethodPost:
+		b.posts++
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"7","createdAt":"2022-11-20T10:00:00.000Z"}`))
+	case http.MethodPatch:
+		b.patches++
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// newSyncedUser returns janet as created in the backend with id 7.
+func newSyncedUser() *usersv1beta1.USER {
+	id := "7"
+	user := newTestUser()
+	user.Finalizers = []string{ctrlFinalizer}
+	user.Status.ExternalID = &id
+	return user
+}
+
+func TestDriftPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		policy      usersv1beta1.DriftPolicy
+		patches     int
+		drifted     []string
+		readyReason string
+	}{{
+		policy:      "",
+		patches:     1,
+		readyReason: usersv1beta1.ReasonAvailable,
+	}, {
+		policy:      usersv1beta1.DriftPolicyCorrect,
+		patches:     1,
+		readyReason: usersv1beta1.ReasonAvailable,
+	}, {
+		policy:      usersv1beta1.DriftPolicyReport,
+		drifted:     []string{"name.first"},
+		readyReason: usersv1beta1.ReasonDrifted,
+	}} {
+		name := string(tc.policy)
+		if name == "" {
+			name = "default"
+		}
+		t.Run(name, func(t *testing.T) {
+			user := newSyncedUser()
+			user.Spec.DriftPolicy = tc.policy
+			backend := &driftedBackend{}
+			r := newTestReconciler(t, backend, user)
+
+			got := reconcileUser(t, r, client.ObjectKeyFromObject(user))
+			if backend.patches != tc.patches {
+				t.Errorf("backend was patched %d times, want %d", backend.patches, tc.patches)
+			}
+			if !reflect.DeepEqual(got.Status.DriftedFields, tc.drifted) {
+				t.Errorf("driftedFields = %v, want %v", got.Status.DriftedFields, tc.drifted)
+			}
+			ready := meta.FindStatusCondition(got.Status.Conditions, usersv1beta1.ConditionReady)
+			if ready == nil || ready.Status != metav1.ConditionTrue || ready.Reason != tc.readyReason {
+				t.Errorf("Ready = %+v, want True with reason %s", ready, tc.readyReason)
+			}
+			if tc.patches == 0 && (got.Status.AtProvider == nil || got.Status.AtProvider.Name.First != "Jane") {
+				t.Errorf("atProvider = %+v, want the drifted backend user", got.Status.AtProvider)
+			}
+		})
+	}
+}
+
+func TestSyncIsDueAfterSyncInterval(t *testing.T) {
+	user := newSyncedUser()
+	user.Spec.DriftPolicy = usersv1beta1.DriftPolicyReport
+	user.Spec.SyncInterval = &metav1.Duration{Duration: 5 * time.Minute}
+	backend := &driftedBackend{}
+	r := newTestReconciler(t, backend, user)
+	key := client.ObjectKeyFromObject(user)
+	reconcile := func() ctrl.Result {
+		t.Helper()
+		result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+		if err != nil {
+			t.Fatalf("Reconcile: %v", err)
+		}
+		return result
+	}
+
+	if result := reconcile(); result.RequeueAfter != 5*time.Minute {
+		t.Errorf("sync requeued after %v, want the 5m syncInterval", result.RequeueAfter)
+	}
+	// A reconcile in between waits for the rest of the interval
+	if result := reconcile(); result.RequeueAfter <= 4*time.Minute || result.RequeueAfter > 5*time.Minute {
+		t.Errorf("reconcile before the sync is due requeued after %v, want the rest of 5m", result.RequeueAfter)
+	}
+	if backend.gets != 1 {
+		t.Errorf("backend was read %d times, want once per syncInterval", backend.gets)
+	}
+
+	// Once the interval passed, the backend is read again
+	synced := &usersv1beta1.USER{}
+	if err := r.Get(context.Background(), key, synced); err != nil {
+		t.Fatal(err)
+	}
+	synced.Status.LastSyncTime = &metav1.Time{Time: synced.Status.LastSyncTime.Add(-5 * time.Minute)}
+	if err := r.Status().Update(context.Background(), synced); err != nil {
+		t.Fatal(err)
+	}
+	if result := reconcile(); result.RequeueAfter != 5*time.Minute || backend.gets != 2 {
+		t.Errorf("due sync read the backend %d times and requeued after %v, want once and 5m", backend.gets-1, result.RequeueAfter)
+	}
+}
+
+func TestDriftIsPatchedByStatusID(t *testing.T) {
+	user := newSyncedUser()
+	var patched string
+	r := newTestReconciler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			// The backend leaves the id out of the user it returns
+			_, _ = w.Write([]byte(`{"data":{"email":"janet.weaver@reqres.in","first_name":"Jane","last_name":"Weaver"}}`))
+		case http.MethodPatch:
+			patched = r.URL.Path
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}), user)
+
+	reconcileUser(t, r, client.ObjectKeyFromObject(user))
+	if patched != "/api/users/7" {
+		t.Errorf("patched %q, want /api/users/7", patched)
+	}
+}
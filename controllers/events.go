@@ -0,0 +1,159 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	goerrors "errors"
+	"strconv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	usersv1beta1 "github.com/adrafiq/reqres-controller/api/v1beta1"
+	reqres "github.com/adrafiq/reqres-controller/pkg/reqres"
+)
+
+// Event reasons. They are part of the controller's interface, alerts and
+// dashboards select on them, so they must not change.
+const (
+	ReasonCreated            = "Created"
+	ReasonImported           = "Imported"
+	ReasonUpdated            = "Updated"
+	ReasonDeleted            = "Deleted"
+	ReasonDriftDetected      = "DriftDetected"
+	ReasonBackendUnavailable = "BackendUnavailable"
+	ReasonValidationFailed   = "ValidationFailed"
+)
+
+// Annotations carried by events, so that tooling does not parse messages.
+const (
+	externalIDEventAnnotation = "users.reqres.in/external-id"
+	httpStatusEventAnnotation = "users.reqres.in/http-status"
+)
+
+// recordEvent emits an event about userCR concerning the backend user id,
+// which may be empty if the user does not exist yet.
+func (r *USERReconciler) recordEvent(userCR *usersv1beta1.USER, eventtype, reason, id string, messageFmt string, args ...interface{}) {
+	if r.Recorder == nil {
+		return
+	}
+	var annotations map[string]string
+	if id != "" {
+		annotations = map[string]string{externalIDEventAnnotation: id}
+	}
+	r.Recorder.AnnotatedEventf(userCR, annotations, eventtype, reason, messageFmt, args...)
+}
+
+// recordBackendError emits a warning for a failed backend call. Errors the
+// backend will keep returning for this spec are reported as ValidationFailed.
+func (r *USERReconciler) recordBackendError(userCR *usersv1beta1.USER, id string, err error) {
+	if r.Recorder == nil {
+		return
+	}
+	reason := ReasonBackendUnavailable
+	if goerrors.Is(err, reqres.ErrPermanent) {
+		reason = ReasonValidationFailed
+	}
+	annotations := map[string]string{}
+	if id != "" {
+		annotations[externalIDEventAnnotation] = id
+	}
+	if status := reqres.StatusCode(err); status != 0 {
+		annotations[httpStatusEventAnnotation] = strconv.Itoa(status)
+	}
+	if id == "" {
+		r.Recorder.AnnotatedEventf(userCR, annotations, corev1.EventTypeWarning, reason, "%v", err)
+		return
+	}
+	r.Recorder.AnnotatedEventf(userCR, annotations, corev1.EventTypeWarning, reason, "backend user %s: %v", id, err)
+}
+
+// maxTrackedWarnings bounds the memory of RateLimitedRecorder before expired
+// entries are dropped.
+const maxTrackedWarnings = 1024
+
+// RateLimitedRecorder passes Normal events through and drops a Warning if
+// one with the same reason was recorded for the same object within
+// Interval. A failing backend otherwise adds an event on every retry.
+type RateLimitedRecorder struct {
+	record.EventRecorder
+	Interval time.Duration
+
+	mu   sync.Mutex
+	last map[warningKey]time.Time
+}
+
+type warningKey struct {
+	uid    types.UID
+	reason string
+}
+
+func NewRateLimitedRecorder(recorder record.EventRecorder, interval time.Duration) *RateLimitedRecorder {
+	return &RateLimitedRecorder{
+		EventRecorder: recorder,
+		Interval:      interval,
+		last:          map[warningKey]time.Time{},
+	}
+}
+
+func (r *RateLimitedRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if r.allow(object, eventtype, reason) {
+		r.EventRecorder.Event(object, eventtype, reason, message)
+	}
+}
+
+func (r *RateLimitedRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	if r.allow(object, eventtype, reason) {
+		r.EventRecorder.Eventf(object, eventtype, reason, messageFmt, args...)
+	}
+}
+
+func (r *RateLimitedRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	if r.allow(object, eventtype, reason) {
+		r.EventRecorder.AnnotatedEventf(object, annotations, eventtype, reason, messageFmt, args...)
+	}
+}
+
+func (r *RateLimitedRecorder) allow(object runtime.Object, eventtype, reason string) bool {
+	if eventtype != corev1.EventTypeWarning || r.Interval <= 0 {
+		return true
+	}
+	uidGetter, ok := object.(interface{ GetUID() types.UID })
+	if !ok {
+		return true
+	}
+	key := warningKey{uid: uidGetter.GetUID(), reason: reason}
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if last, ok := r.last[key]; ok && now.Sub(last) < r.Interval {
+		return false
+	}
+	if len(r.last) >= maxTrackedWarnings {
+		for k, last := range r.last {
+			if now.Sub(last) >= r.Interval {
+				delete(r.last, k)
+			}
+		}
+	}
+	r.last[key] = now
+	return true
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRateLimitedRecorder(t *testing.T) {
+	fake := record.NewFakeRecorder(10)
+	recorder := NewRateLimitedRecorder(fake, time.Minute)
+	janet := newTestUser()
+	janet.UID = "janet"
+	emma := newTestUser()
+	emma.Name, emma.UID = "emma", "emma"
+
+	recorder.Eventf(janet, corev1.EventTypeWarning, ReasonBackendUnavailable, "attempt %d", 1)
+	recorder.Eventf(janet, corev1.EventTypeWarning, ReasonBackendUnavailable, "attempt %d", 2)
+	recorder.AnnotatedEventf(janet, nil, corev1.EventTypeWarning, ReasonBackendUnavailable, "attempt %d", 3)
+	recorder.Event(janet, corev1.EventTypeWarning, ReasonValidationFailed, "invalid")
+	recorder.Event(emma, corev1.EventTypeWarning, ReasonBackendUnavailable, "attempt 1")
+	recorder.Event(janet, corev1.EventTypeNormal, ReasonCreated, "created")
+	recorder.Event(janet, corev1.EventTypeNormal, ReasonCreated, "created")
+
+	// Once the interval passed, the warning is recorded again
+	recorder.last[warningKey{uid: janet.UID, reason: ReasonBackendUnavailable}] = time.Now().Add(-time.Minute)
+	recorder.Event(janet, corev1.EventTypeWarning, ReasonBackendUnavailable, "attempt 4")
+
+	close(fake.Events)
+	var got []string
+	for event := range fake.Events {
+		got = append(got, event)
+	}
+	want := []string{
+		"Warning BackendUnavailable attempt 1",
+		"Warning ValidationFailed invalid",
+		"Warning BackendUnavailable attempt 1",
+		"Normal Created created",
+		"Normal Created created",
+		"Warning BackendUnavailable attempt 4",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("recorded %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	usersv1beta1 "github.com/adrafiq/reqres-controller/api/v1beta1"
+	"github.com/adrafiq/reqres-controller/pkg/backend"
+	envConfig "github.com/adrafiq/reqres-controller/pkg/config"
+	reqres "github.com/adrafiq/reqres-controller/pkg/reqres"
+)
+
+// These tests run the reconcilers against a fake cluster instead of the
+// envtest suite, so that they need no control plane binaries.
+
+// newTestScheme returns a scheme with the core and v1beta1 types.
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := usersv1beta1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
+
+// newTestReconciler returns a USERReconciler over a fake cluster holding
+// objs. Its default backend "reqres" is a client, without retries, of a
+// server answering with handler. Without handler, it is an in-memory
+// backend named "memory".
+func newTestReconciler(t *testing.T, handler http.Handler, objs ...client.Object) *USERReconciler {
+	t.Helper()
+	scheme := newTestScheme(t)
+	r := &USERReconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+		Scheme:   scheme,
+		Config:   envConfig.New(),
+		Backends: backend.NewRegistry("memory", backend.NewMemory()),
+	}
+	if handler != nil {
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+		reqresClient := reqres.NewClient(server.URL, nil)
+		reqresClient.Retry = reqres.NoRetry{}
+		r.Backends = backend.NewRegistry("reqres", &reqresClient)
+	}
+	return r
+}
+
+// newTestUser returns janet in the default namespace.
+func newTestUser() *usersv1beta1.USER {
+	return &usersv1beta1.USER{
+		ObjectMeta: metav1.ObjectMeta{Name: "janet", Namespace: "default", Generation: 1},
+		Spec: usersv1beta1.USERSpec{
+			Email: "janet.weaver@reqres.in",
+			Name:  usersv1beta1.UserName{First: "Janet", Last: "Weaver"},
+		},
+	}
+}
+
+// reconcileUser reconciles the USER key and returns it as stored afterwards,
+// or nil once it is gone.
+func reconcileUser(t *testing.T, r *USERReconciler, key types.NamespacedName) *usersv1beta1.USER {
+	t.Helper()
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	got := &usersv1beta1.USER{}
+	if err := r.Get(context.Background(), key, got); errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
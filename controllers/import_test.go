@@ -0,0 +1,88 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	usersv1beta1 "github.com/adrafiq/reqres-controller/api/v1beta1"
+)
+
+func TestImportUser(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		status      int
+		body        string
+		wantID      string
+		readyReason string
+		event       string
+	}{{
+		name:        "existing user",
+		status:      http.StatusOK,
+		body:        `{"data":{"id":7,"email":"janet.weaver@reqres.in","first_name":"Janet","last_name":"Weaver"}}`,
+		wantID:      "7",
+		readyReason: usersv1beta1.ReasonAvailable,
+		event:       "Normal Imported imported backend user 7",
+	}, {
+		name:        "missing user",
+		status:      http.StatusNotFound,
+		body:        `{}`,
+		readyReason: usersv1beta1.ReasonImportNotFound,
+		event:       "Warning ValidationFailed no backend user 7 to import",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			user := newTestUser()
+			user.Spec.ImportID = "7"
+			var requests []string
+			r := newTestReconciler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.Method+" "+r.URL.Path)
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}), user)
+			recorder := record.NewFakeRecorder(10)
+			r.Recorder = recorder
+
+			got := reconcileUser(t, r, client.ObjectKeyFromObject(user))
+			if len(requests) != 1 || requests[0] != "GET /api/users/7" {
+				t.Errorf("requests = %q, want only GET /api/users/7", requests)
+			}
+			if tc.wantID == "" && got.Status.Created() {
+				t.Errorf("externalID = %s, want none", *got.Status.ExternalID)
+			} else if tc.wantID != "" && (!got.Status.Created() || *got.Status.ExternalID != tc.wantID) {
+				t.Errorf("status = %+v, want externalID %s", got.Status, tc.wantID)
+			}
+			ready := meta.FindStatusCondition(got.Status.Conditions, usersv1beta1.ConditionReady)
+			if ready == nil || ready.Reason != tc.readyReason || (ready.Status == metav1.ConditionTrue) != (tc.wantID != "") {
+				t.Errorf("Ready = %+v, want reason %s", ready, tc.readyReason)
+			}
+			select {
+			case event := <-recorder.Events:
+				if event != tc.event {
+					t.Errorf("event = %q, want %q", event, tc.event)
+				}
+			default:
+				t.Errorf("no event recorded, want %q", tc.event)
+			}
+		})
+	}
+}
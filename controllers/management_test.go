@@ -0,0 +1,97 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	usersv1beta1 "github.com/adrafiq/reqres-controller/api/v1beta1"
+)
+
+// syncNow requests a sync of the USER key ahead of its sync interval and
+// reconciles it.
+func syncNow(t *testing.T, r *USERReconciler, key types.NamespacedName, request string) *usersv1beta1.USER {
+	t.Helper()
+	user := &usersv1beta1.USER{}
+	if err := r.Get(context.Background(), key, user); err != nil {
+		t.Fatal(err)
+	}
+	if user.Annotations == nil {
+		user.Annotations = map[string]string{}
+	}
+	user.Annotations[usersv1beta1.SyncRequestAnnotation] = request
+	if err := r.Update(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return reconcileUser(t, r, key)
+}
+
+func TestObserveOnlyNeverWrites(t *testing.T) {
+	user := newTestUser()
+	user.Spec.ImportID = "7"
+	user.Spec.ManagementPolicy = usersv1beta1.ManagementPolicyObserveOnly
+	backend := &driftedBackend{}
+	r := newTestReconciler(t, backend, user)
+	key := client.ObjectKeyFromObject(user)
+
+	for i, got := range []*usersv1beta1.USER{reconcileUser(t, r, key), syncNow(t, r, key, "1")} {
+		if !got.Status.Created() || *got.Status.ExternalID != "7" {
+			t.Fatalf("sync %d: status = %+v, want externalID 7", i, got.Status)
+		}
+		if got.Status.AtProvider == nil || got.Status.AtProvider.Name.First != "Jane" {
+			t.Errorf("sync %d: atProvider = %+v, want the backend user", i, got.Status.AtProvider)
+		}
+	}
+	if backend.gets != 2 {
+		t.Errorf("backend was read %d times, want 2", backend.gets)
+	}
+	if backend.posts != 0 || backend.patches != 0 {
+		t.Errorf("backend received %d creates and %d patches, want none", backend.posts, backend.patches)
+	}
+}
+
+func TestCreateOnlyNeverPatches(t *testing.T) {
+	user := newTestUser()
+	user.Spec.ManagementPolicy = usersv1beta1.ManagementPolicyCreateOnly
+	backend := &driftedBackend{}
+	r := newTestReconciler(t, backend, user)
+	key := client.ObjectKeyFromObject(user)
+
+	if got := reconcileUser(t, r, key); !got.Status.Created() || *got.Status.ExternalID != "7" {
+		t.Fatalf("status = %+v, want externalID 7", got.Status)
+	}
+	syncNow(t, r, key, "1")
+	got := syncNow(t, r, key, "2")
+	if backend.gets != 2 {
+		t.Errorf("backend was read %d times, want 2", backend.gets)
+	}
+	if backend.posts != 1 {
+		t.Errorf("backend user was created %d times, want once", backend.posts)
+	}
+	if backend.patches != 0 {
+		t.Errorf("backend was patched %d times, want never", backend.patches)
+	}
+	ready := meta.FindStatusCondition(got.Status.Conditions, usersv1beta1.ConditionReady)
+	if ready == nil || ready.Reason != usersv1beta1.ReasonDrifted {
+		t.Errorf("Ready = %+v, want reason %s", ready, usersv1beta1.ReasonDrifted)
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	usersv1beta1 "github.com/adrafiq/reqres-controller/api/v1beta1"
+)
+
+// Names of the metrics exported by the USER controller.
+const (
+	MetricOperations = "reqres_user_operations_total"
+	MetricDrift      = "reqres_user_drift_total"
+	MetricUsers      = "reqres_users"
+)
+
+// Outcomes of backend mutations, as counted by MetricOperations.
+const (
+	outcomeSuccess = "success"
+	outcomeError   = "error"
+)
+
+var operationsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: MetricOperations,
+		Help: "Number of backend users created, updated and deleted, by operation and outcome.",
+	},
+	[]string{"operation", "outcome"},
+)
+
+var driftTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: MetricDrift,
+		Help: "Number of times a backend user was found to differ from its spec, by field.",
+	},
+	[]string{"field"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(operationsTotal, driftTotal)
+}
+
+// recordOperation counts the outcome of a backend mutation.
+func recordOperation(operation string, err error) {
+	outcome := outcomeSuccess
+	if err != nil {
+		outcome = outcomeError
+	}
+	operationsTotal.WithLabelValues(operation, outcome).Inc()
+}
+
+// userCollectTimeout bounds listing USER objects on a scrape.
+const userCollectTimeout = 10 * time.Second
+
+var usersDesc = prometheus.NewDesc(
+	MetricUsers,
+	"Number of USER objects, by condition type and status.",
+	[]string{"condition", "status"},
+	nil,
+)
+
+// UserCollector reports MetricUsers by counting USER objects at scrape
+// time, so that the gauge never goes stale as objects come and go.
+type UserCollector struct {
+	reader client.Reader
+}
+
+// NewUserCollector counts the objects seen through reader, which should be
+// the manager's cached client.
+func NewUserCollector(reader client.Reader) *UserCollector {
+	return &UserCollector{reader: reader}
+}
+
+func (c *UserCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- usersDesc
+}
+
+func (c *UserCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), userCollectTimeout)
+	defer cancel()
+	users := &usersv1beta1.USERList{}
+	if err := c.reader.List(ctx, users); err != nil {
+		ch <- prometheus.NewInvalidMetric(usersDesc, err)
+		return
+	}
+	counts := map[[2]string]int{}
+	for _, user := range users.Items {
+		for _, condition := range user.Status.Conditions {
+			counts[[2]string{condition.Type, string(condition.Status)}]++
+		}
+	}
+	for key, count := range counts {
+		ch <- prometheus.MustNewConstMetric(usersDesc, prometheus.GaugeValue, float64(count), key[0], key[1])
+	}
+}
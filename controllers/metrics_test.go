@@ -0,0 +1,62 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	usersv1beta1 "github.com/adrafiq/reqres-controller/api/v1beta1"
+)
+
+func TestUserCollector(t *testing.T) {
+	user := func(name string, ready, synced metav1.ConditionStatus) client.Object {
+		user := newTestUser()
+		user.Name = name
+		if ready != "" {
+			setCondition(user, usersv1beta1.ConditionReady, ready, usersv1beta1.ReasonAvailable, "")
+		}
+		if synced != "" {
+			setCondition(user, usersv1beta1.ConditionSynced, synced, usersv1beta1.ReasonAvailable, "")
+		}
+		return user
+	}
+	r := newTestReconciler(t, nil,
+		user("janet", metav1.ConditionTrue, metav1.ConditionTrue),
+		user("emma", metav1.ConditionTrue, metav1.ConditionTrue),
+		user("eve", metav1.ConditionFalse, metav1.ConditionFalse),
+		user("tracey", metav1.ConditionUnknown, metav1.ConditionTrue),
+		user("new", "", ""),
+	)
+
+	expected := `
+# HELP reqres_users Number of USER objects, by condition type and status.
+# TYPE reqres_users gauge
+reqres_users{condition="Ready",status="False"} 1
+reqres_users{condition="Ready",status="True"} 2
+reqres_users{condition="Ready",status="Unknown"} 1
+reqres_users{condition="Synced",status="False"} 1
+reqres_users{condition="Synced",status="True"} 3
+`
+	if err := testutil.CollectAndCompare(NewUserCollector(r.Client), strings.NewReader(expected), MetricUsers); err != nil {
+		t.Error(err)
+	}
+}
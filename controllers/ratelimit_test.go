@@ -0,0 +1,63 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	reqres "github.com/adrafiq/reqres-controller/pkg/reqres"
+)
+
+func TestReconcilersShareTheRateLimit(t *testing.T) {
+	janet := newTestUser()
+	emma := newTestUser()
+	emma.Name, emma.Spec.Email = "emma", "emma.wong@reqres.in"
+	var creates int32
+	first := newTestReconciler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := atomic.AddInt32(&creates, 1)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprintf(w, `{"id":"%d"}`, id)
+	}), janet, emma)
+	// A second reconciler built from the same backends, as main does
+	second := &USERReconciler{Client: first.Client, Scheme: first.Scheme, Config: first.Config, Backends: first.Backends}
+
+	const qps = 20
+	firstBackend, _ := first.Backends.Get("reqres")
+	firstBackend.(*reqres.Client).Limiter = rate.NewLimiter(qps, 1)
+	secondBackend, _ := second.Backends.Get("reqres")
+	if firstBackend != secondBackend || secondBackend.(*reqres.Client).Limiter != firstBackend.(*reqres.Client).Limiter {
+		t.Fatal("reconcilers use different clients or rate limiters")
+	}
+
+	// The second create waits for the token the first one took
+	start := time.Now()
+	reconcileUser(t, first, client.ObjectKeyFromObject(janet))
+	reconcileUser(t, second, client.ObjectKeyFromObject(emma))
+	if creates != 2 {
+		t.Fatalf("backend received %d creates, want 2", creates)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second/qps*4/5 {
+		t.Errorf("two creates took %v, want them spaced by the %d qps limit", elapsed, qps)
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	usersv1beta1 "github.com/adrafiq/reqres-controller/api/v1beta1"
+	"github.com/spf13/viper"
+)
+
+// ReqresBackendReconciler keeps the client of every ReqresBackend current
+// and probes its endpoint, reporting the outcome in the Ready condition.
+type ReqresBackendReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Config *viper.Viper
+	// Clients is shared with the USERReconciler.
+	Clients *ReqresClients
+}
+
+//+kubebuilder:rbac:groups=users.reqres.in,resources=reqresbackends,verbs=get;list;watch
+//+kubebuilder:rbac:groups=users.reqres.in,resources=reqresbackends/status,verbs=get;update;patch
+
+// Reconcile (re)builds the backend's client and probes the endpoint with a
+// single-user list, once per REQRES_BACKEND_PROBE_INTERVAL. Status is only
+// written when the outcome changed.
+func (r *ReqresBackendReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	backendCR := &usersv1beta1.ReqresBackend{}
+	if err := r.Get(ctx, req.NamespacedName, backendCR); err != nil {
+		if errors.IsNotFound(err) {
+			r.Clients.Forget(req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	base := backendCR.Status.DeepCopy()
+	backendCR.Status.ObservedGeneration = backendCR.Generation
+	result := r.probe(ctx, backendCR)
+	if equality.Semantic.DeepEqual(*base, backendCR.Status) {
+		return result, nil
+	}
+	if ready := meta.FindStatusCondition(backendCR.Status.Conditions, usersv1beta1.ConditionReady); ready != nil {
+		logger.Info("backend health changed", "ready", ready.Status, "reason", ready.Reason, "message", ready.Message)
+	}
+	original := backendCR.DeepCopy()
+	original.Status = *base
+	if err := r.Status().Patch(ctx, backendCR, client.MergeFrom(original)); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	return result, nil
+}
+
+// probe refreshes the client of backendCR and sets its Ready condition. A
+// backend that cannot be configured is retried once the backend or its
+// Secrets change.
+func (r *ReqresBackendReconciler) probe(ctx context.Context, backendCR *usersv1beta1.ReqresBackend) ctrl.Result {
+	reqresClient, err := r.Clients.Refresh(ctx, r.Client, backendCR)
+	if err != nil {
+		setBackendCondition(backendCR, metav1.ConditionFalse, usersv1beta1.ReasonInvalidBackend, err.Error())
+		return ctrl.Result{}
+	}
+	if retryAfter := reqresClient.RetryAfter(); retryAfter > 0 {
+		setBackendCondition(backendCR, metav1.ConditionFalse, usersv1beta1.ReasonCircuitOpen, "calls are suspended after repeated failures")
+		return ctrl.Result{RequeueAfter: retryAfter}
+	}
+	interval := r.Config.GetDuration("REQRES_BACKEND_PROBE_INTERVAL")
+	if _, err := reqresClient.ListUsers(ctx, 1, 1); err != nil {
+		setBackendCondition(backendCR, metav1.ConditionFalse, usersv1beta1.ReasonUnreachable, err.Error())
+		return ctrl.Result{RequeueAfter: interval}
+	}
+	setBackendCondition(backendCR, metav1.ConditionTrue, usersv1beta1.ReasonAvailable, "backend answered")
+	return ctrl.Result{RequeueAfter: interval}
+}
+
+func setBackendCondition(backendCR *usersv1beta1.ReqresBackend, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&backendCR.Status.Conditions, metav1.Condition{
+		Type:               usersv1beta1.ConditionReady,
+		Status:             status,
+		ObservedGeneration: backendCR.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// backendsForSecret maps a Secret to the ReqresBackends referencing it.
+func (r *ReqresBackendReconciler) backendsForSecret(obj client.Object) []reconcile.Request {
+	backends := &usersv1beta1.ReqresBackendList{}
+	if err := r.List(context.Background(), backends,
+		client.InNamespace(obj.GetNamespace()),
+		client.MatchingFields{reqresBackendSecretIndex: obj.GetName()},
+	); err != nil {
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(backends.Items))
+	for _, backendCR := range backends.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&backendCR)})
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ReqresBackendReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &usersv1beta1.ReqresBackend{}, reqresBackendSecretIndex, reqresBackendSecrets); err != nil {
+		return err
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&usersv1beta1.ReqresBackend{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.backendsForSecret), builder.OnlyMetadata).
+		Complete(r)
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	usersv1beta1 "github.com/adrafiq/reqres-controller/api/v1beta1"
+	envConfig "github.com/adrafiq/reqres-controller/pkg/config"
+	reqres "github.com/adrafiq/reqres-controller/pkg/reqres"
+)
+
+// keyedBackend is a reqres API accepting a single API key, which the test
+// rotates.
+type keyedBackend struct {
+	mu      sync.Mutex
+	key     string
+	creates int
+}
+
+func (b *keyedBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if r.Header.Get(defaultAPIKeyHeader) != b.key {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		b.creates++
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"7"}`))
+	case http.MethodGet:
+		_, _ = w.Write([]byte(`{"page":1,"per_page":1,"total":0,"total_pages":0,"data":[]}`))
+	}
+}
+
+func (b *keyedBackend) rotate(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.key = key
+}
+
+func TestReqresBackendHealthAndRotation(t *testing.T) {
+	ctx := context.Background()
+	users := &keyedBackend{key: "first"}
+	server := httptest.NewServer(users)
+	defer server.Close()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "tenant-a", Namespace: "tenant-a"},
+		Data:       map[string][]byte{"api-key": []byte("first\n")},
+	}
+	backendCR := &usersv1beta1.ReqresBackend{
+		ObjectMeta: metav1.ObjectMeta{Name: "tenant-a", Namespace: "tenant-a", Generation: 1},
+		Spec: usersv1beta1.ReqresBackendSpec{
+			URL: server.URL + "/",
+			APIKey: &usersv1beta1.BackendAPIKey{SecretKeyRef: corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "tenant-a"},
+				Key:                  "api-key",
+			}},
+			RateLimit: &usersv1beta1.BackendRateLimit{QPS: 50},
+		},
+	}
+	user := newTestUser()
+	user.Namespace = "tenant-a"
+	user.Spec.BackendRef = &usersv1beta1.ReqresBackendReference{Name: "tenant-a"}
+	u := newTestReconciler(t, nil, secret, backendCR, user)
+	k8sClient := u.Client
+	clients := NewReqresClients(func() *reqres.Client {
+		client := reqres.NewClient("", nil)
+		client.Retry = reqres.NoRetry{}
+		return &client
+	})
+	u.ReqresClients = clients
+	r := &ReqresBackendReconciler{
+		Client:  k8sClient,
+		Scheme:  u.Scheme,
+		Config:  envConfig.New(),
+		Clients: clients,
+	}
+	key := types.NamespacedName{Name: "tenant-a", Namespace: "tenant-a"}
+	reconcile := func() *metav1.Condition {
+		t.Helper()
+		if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+			t.Fatal(err)
+		}
+		got := &usersv1beta1.ReqresBackend{}
+		if err := k8sClient.Get(ctx, key, got); err != nil {
+			t.Fatal(err)
+		}
+		return meta.FindStatusCondition(got.Status.Conditions, usersv1beta1.ConditionReady)
+	}
+
+	if ready := reconcile(); ready == nil || ready.Status != metav1.ConditionTrue || ready.Reason != usersv1beta1.ReasonAvailable {
+		t.Fatalf("Ready = %+v", ready)
+	}
+	first, err := clients.Get(ctx, k8sClient, backendCR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reads := &getCounter{Client: k8sClient}
+	if again, _ := clients.Get(ctx, reads, backendCR); again != first || reads.gets != 0 {
+		t.Errorf("client was rebuilt without a change, or its Secret read %d times", reads.gets)
+	}
+	if first.Limiter == nil || first.Limiter.Limit() != 50 || first.Limiter.Burst() != 50 {
+		t.Errorf("rate limit is %v", first.Limiter)
+	}
+
+	// The USER is created through the backend's client
+	user = reconcileUser(t, u, types.NamespacedName{Name: "janet", Namespace: "tenant-a"})
+	if !user.Status.Created() || user.Status.Backend != "ReqresBackend/tenant-a" || users.creates != 1 {
+		t.Errorf("user status = %+v after %d creates", user.Status, users.creates)
+	}
+
+	// A key the backend no longer accepts makes it unhealthy until the
+	// Secret is rotated
+	users.rotate("second")
+	if ready := reconcile(); ready == nil || ready.Status != metav1.ConditionFalse || ready.Reason != usersv1beta1.ReasonUnreachable {
+		t.Fatalf("Ready with a stale key = %+v", ready)
+	}
+	secret.Data["api-key"] = []byte("second")
+	if err := k8sClient.Update(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+	if ready := reconcile(); ready == nil || ready.Status != metav1.ConditionTrue {
+		t.Fatalf("Ready after rotation = %+v", ready)
+	}
+	if rotated, _ := clients.Get(ctx, k8sClient, backendCR); rotated == first {
+		t.Error("client was not rebuilt after the Secret rotated")
+	}
+
+	if err := k8sClient.Delete(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+	if ready := reconcile(); ready == nil || ready.Reason != usersv1beta1.ReasonInvalidBackend {
+		t.Errorf("Ready without its Secret = %+v", ready)
+	}
+	if _, err := clients.Get(ctx, k8sClient, backendCR); err == nil {
+		t.Error("client was kept after its Secret was deleted")
+	}
+}
+
+// getCounter counts the objects read through a client, only those of the
+// type of kind if set.
+type getCounter struct {
+	client.Client
+	kind client.Object
+	gets int
+}
+
+func (c *getCounter) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if c.kind == nil || reflect.TypeOf(obj) == reflect.TypeOf(c.kind) {
+		c.gets++
+	}
+	return c.Client.Get(ctx, key, obj, opts...)
+}
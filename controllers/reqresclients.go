@@ -0,0 +1,276 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	usersv1beta1 "github.com/adrafiq/reqres-controller/api/v1beta1"
+	reqres "github.com/adrafiq/reqres-controller/pkg/reqres"
+)
+
+// defaultAPIKeyHeader carries the API key of a ReqresBackend that names no
+// header, as reqres.in expects it.
+const defaultAPIKeyHeader = "x-api-key"
+
+// reqresBackendSecretIndex indexes ReqresBackends by the names of the
+// Secrets they reference.
+const reqresBackendSecretIndex = "spec.secretRefs"
+
+// ReqresClients caches one client per ReqresBackend. A client is rebuilt
+// once the backend's spec changed, or once the ReqresBackendReconciler,
+// which watches the Secrets referenced, refreshes it.
+type ReqresClients struct {
+	// NewClient returns a client with the controller's defaults, which the
+	// backend's spec then overrides.
+	NewClient func() *reqres.Client
+
+	mu      sync.Mutex
+	clients map[types.NamespacedName]cachedClient
+}
+
+type cachedClient struct {
+	client *reqres.Client
+	// spec is the backend's spec the client was built from, and version
+	// identifies it along with the Secret contents.
+	spec    string
+	version string
+}
+
+// NewReqresClients returns an empty cache building clients from newClient.
+func NewReqresClients(newClient func() *reqres.Client) *ReqresClients {
+	return &ReqresClients{NewClient: newClient, clients: map[types.NamespacedName]cachedClient{}}
+}
+
+// Get returns the client of backendCR. Only if there is none for its spec
+// yet, it is built from the Secrets read through reader.
+func (c *ReqresClients) Get(ctx context.Context, reader client.Reader, backendCR *usersv1beta1.ReqresBackend) (*reqres.Client, error) {
+	spec, err := json.Marshal(backendCR.Spec)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	cached, ok := c.clients[client.ObjectKeyFromObject(backendCR)]
+	c.mu.Unlock()
+	if ok && cached.spec == string(spec) {
+		return cached.client, nil
+	}
+	return c.Refresh(ctx, reader, backendCR)
+}
+
+// Refresh reads the Secrets of backendCR through reader and rebuilds its
+// client if they or the spec changed. A backend that cannot be configured
+// loses its client.
+func (c *ReqresClients) Refresh(ctx context.Context, reader client.Reader, backendCR *usersv1beta1.ReqresBackend) (*reqres.Client, error) {
+	key := client.ObjectKeyFromObject(backendCR)
+	built, err := c.refresh(ctx, reader, backendCR)
+	if err != nil {
+		c.mu.Lock()
+		delete(c.clients, key)
+		c.mu.Unlock()
+	}
+	return built, err
+}
+
+func (c *ReqresClients) refresh(ctx context.Context, reader client.Reader, backendCR *usersv1beta1.ReqresBackend) (*reqres.Client, error) {
+	secrets := map[string]*corev1.Secret{}
+	for _, name := range reqresBackendSecrets(backendCR) {
+		secret := &corev1.Secret{}
+		if err := reader.Get(ctx, types.NamespacedName{Namespace: backendCR.Namespace, Name: name}, secret); err != nil {
+			return nil, fmt.Errorf("Secret %s: %w", name, err)
+		}
+		secrets[name] = secret
+	}
+	spec, err := json.Marshal(backendCR.Spec)
+	if err != nil {
+		return nil, err
+	}
+	version := clientVersion(spec, secrets)
+	key := client.ObjectKeyFromObject(backendCR)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.clients[key]; ok && cached.version == version {
+		return cached.client, nil
+	}
+	built, err := c.build(backendCR.Spec, secrets)
+	if err != nil {
+		return nil, err
+	}
+	if built.Breaker != nil {
+		built.Breaker.Report(reqresBackendPrefix+key.Name, key.Namespace)
+	}
+	c.clients[key] = cachedClient{client: built, spec: string(spec), version: version}
+	return built, nil
+}
+
+// Forget drops the client of a deleted ReqresBackend.
+func (c *ReqresClients) Forget(key types.NamespacedName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.clients, key)
+	reqres.ForgetBreaker(reqresBackendPrefix+key.Name, key.Namespace)
+}
+
+// clientVersion identifies a backend's spec along with the versions of the
+// Secrets it references.
+func clientVersion(spec []byte, secrets map[string]*corev1.Secret) string {
+	versions := make([]string, 0, len(secrets))
+	for name, secret := range secrets {
+		versions = append(versions, name+"@"+secret.ResourceVersion)
+	}
+	sort.Strings(versions)
+	return string(spec) + strings.Join(versions, ",")
+}
+
+// build applies spec to a new client.
+func (c *ReqresClients) build(spec usersv1beta1.ReqresBackendSpec, secrets map[string]*corev1.Secret) (*reqres.Client, error) {
+	built := c.NewClient()
+	built.HostUrl = strings.TrimSuffix(spec.URL, "/")
+	built.SupportsIdempotencyKey = spec.SupportsIdempotencyKey
+	if apiKey := spec.APIKey; apiKey != nil {
+		value, err := secretKey(secrets, apiKey.SecretKeyRef.Name, apiKey.SecretKeyRef.Key)
+		if err != nil {
+			return nil, err
+		}
+		header := apiKey.Header
+		if header == "" {
+			header = defaultAPIKeyHeader
+		}
+		built.Header = http.Header{}
+		built.Header.Set(header, strings.TrimSpace(string(value)))
+	}
+	if spec.TLS != nil {
+		tlsConfig, err := backendTLSConfig(spec.TLS, secrets)
+		if err != nil {
+			return nil, err
+		}
+		transport, ok := built.HTTPClient.Transport.(*http.Transport)
+		if !ok {
+			transport = http.DefaultTransport.(*http.Transport)
+		}
+		transport = transport.Clone()
+		transport.TLSClientConfig = tlsConfig
+		built.HTTPClient = &http.Client{Transport: transport, Timeout: built.HTTPClient.Timeout}
+	}
+	if limit := spec.RateLimit; limit != nil {
+		burst := int(limit.Burst)
+		if burst == 0 {
+			burst = int(limit.QPS)
+		}
+		built.Limiter = rate.NewLimiter(rate.Limit(limit.QPS), burst)
+	}
+	if timeouts := spec.Timeouts; timeouts != nil {
+		overrideTimeout(&built.Timeouts.Create, timeouts.Create)
+		overrideTimeout(&built.Timeouts.Get, timeouts.Get)
+		overrideTimeout(&built.Timeouts.Update, timeouts.Update)
+		overrideTimeout(&built.Timeouts.Delete, timeouts.Delete)
+	}
+	return built, nil
+}
+
+func overrideTimeout(timeout *time.Duration, override *metav1.Duration) {
+	if override != nil {
+		*timeout = override.Duration
+	}
+}
+
+// backendTLSConfig builds the TLS configuration of a ReqresBackend.
+func backendTLSConfig(spec *usersv1beta1.BackendTLS, secrets map[string]*corev1.Secret) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         spec.ServerName,
+		InsecureSkipVerify: spec.InsecureSkipVerify,
+	}
+	if ref := spec.CASecretRef; ref != nil {
+		pem, err := secretKey(secrets, ref.Name, ref.Key)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("Secret %s: key %s holds no PEM certificate", ref.Name, ref.Key)
+		}
+	}
+	if ref := spec.ClientCertificateSecretRef; ref != nil {
+		cert, err := secretKey(secrets, ref.Name, corev1.TLSCertKey)
+		if err != nil {
+			return nil, err
+		}
+		key, err := secretKey(secrets, ref.Name, corev1.TLSPrivateKeyKey)
+		if err != nil {
+			return nil, err
+		}
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("Secret %s: %w", ref.Name, err)
+		}
+		config.Certificates = []tls.Certificate{pair}
+	}
+	return config, nil
+}
+
+func secretKey(secrets map[string]*corev1.Secret, name, key string) ([]byte, error) {
+	value, ok := secrets[name].Data[key]
+	if !ok {
+		return nil, fmt.Errorf("Secret %s has no key %s", name, key)
+	}
+	return value, nil
+}
+
+// reqresBackendSecrets returns the names of the Secrets a ReqresBackend
+// references, which also are its reqresBackendSecretIndex keys.
+func reqresBackendSecrets(obj client.Object) []string {
+	backendCR, ok := obj.(*usersv1beta1.ReqresBackend)
+	if !ok {
+		return nil
+	}
+	var names []string
+	if apiKey := backendCR.Spec.APIKey; apiKey != nil {
+		names = append(names, apiKey.SecretKeyRef.Name)
+	}
+	if spec := backendCR.Spec.TLS; spec != nil {
+		if spec.CASecretRef != nil {
+			names = append(names, spec.CASecretRef.Name)
+		}
+		if spec.ClientCertificateSecretRef != nil {
+			names = append(names, spec.ClientCertificateSecretRef.Name)
+		}
+	}
+	sort.Strings(names)
+	unique := names[:0]
+	for i, name := range names {
+		if i == 0 || name != names[i-1] {
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
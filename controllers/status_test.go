@@ -0,0 +1,141 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	usersv1beta1 "github.com/adrafiq/reqres-controller/api/v1beta1"
+)
+
+// writeCounter counts the writes made through a client, status included.
+type writeCounter struct {
+	client.Client
+	writes int
+}
+
+func (c *writeCounter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	c.writes++
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *writeCounter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	c.writes++
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *writeCounter) Status() client.StatusWriter {
+	return &statusWriteCounter{StatusWriter: c.Client.Status(), writes: &c.writes}
+}
+
+type statusWriteCounter struct {
+	client.StatusWriter
+	writes *int
+}
+
+func (c *statusWriteCounter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	*c.writes++
+	return c.StatusWriter.Update(ctx, obj, opts...)
+}
+
+func (c *statusWriteCounter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	*c.writes++
+	return c.StatusWriter.Patch(ctx, obj, patch, opts...)
+}
+
+func TestIdleUserIsNotWritten(t *testing.T) {
+	var gets int32
+	id := "7"
+	user := newTestUser()
+	user.Finalizers = []string{ctrlFinalizer}
+	user.Status.ExternalID = &id
+	r := newTestReconciler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&gets, 1)
+		_, _ = w.Write([]byte(`{"data":{"id":7,"email":"janet.weaver@reqres.in","first_name":"Janet","last_name":"Weaver"}}`))
+	}), user)
+	k8sClient := &writeCounter{Client: r.Client}
+	r.Client = k8sClient
+	key := client.ObjectKeyFromObject(user)
+	reconcile := func() ctrl.Result {
+		t.Helper()
+		result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+		if err != nil {
+			t.Fatalf("Reconcile: %v", err)
+		}
+		return result
+	}
+
+	reconcile()
+	if gets != 1 || k8sClient.writes != 1 {
+		t.Fatalf("first sync made %d backend calls and %d writes, want 1 and 1", gets, k8sClient.writes)
+	}
+	for i := 0; i < 3; i++ {
+		if result := reconcile(); result.RequeueAfter <= 0 {
+			t.Errorf("idle reconcile should requeue for the next sync, got %+v", result)
+		}
+	}
+	if gets != 1 || k8sClient.writes != 1 {
+		t.Errorf("idle reconciles made %d backend calls and %d writes, want none", gets-1, k8sClient.writes-1)
+	}
+
+	// A sync request is served once
+	synced := &usersv1beta1.USER{}
+	if err := k8sClient.Get(context.Background(), key, synced); err != nil {
+		t.Fatal(err)
+	}
+	synced.Annotations = map[string]string{usersv1beta1.SyncRequestAnnotation: "2022-11-20T10:00:00Z"}
+	if err := k8sClient.Update(context.Background(), synced); err != nil {
+		t.Fatal(err)
+	}
+	writes := k8sClient.writes
+	reconcile()
+	reconcile()
+	if gets != 2 || k8sClient.writes != writes+1 {
+		t.Errorf("sync request made %d backend calls and %d writes, want 1 and 1", gets-1, k8sClient.writes-writes)
+	}
+}
+
+func TestUserPredicatesIgnoreStatusUpdates(t *testing.T) {
+	old := &usersv1beta1.USER{ObjectMeta: metav1.ObjectMeta{Name: "janet", Generation: 1}}
+
+	statusOnly := old.DeepCopy()
+	statusOnly.Status.ObservedGeneration = 1
+	statusOnly.ResourceVersion = "2"
+	if userPredicates.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: statusOnly}) {
+		t.Errorf("status update should not trigger a reconcile")
+	}
+
+	specChange := old.DeepCopy()
+	specChange.Generation = 2
+	if !userPredicates.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: specChange}) {
+		t.Errorf("spec update should trigger a reconcile")
+	}
+
+	syncRequest := old.DeepCopy()
+	syncRequest.Annotations = map[string]string{usersv1beta1.SyncRequestAnnotation: "now"}
+	if !userPredicates.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: syncRequest}) {
+		t.Errorf("sync request should trigger a reconcile")
+	}
+}
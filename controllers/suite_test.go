@@ -31,6 +31,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	usersv1alpha1 "github.com/adrafiq/reqres-controller/api/v1alpha1"
+	usersv1beta1 "github.com/adrafiq/reqres-controller/api/v1beta1"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -65,6 +66,9 @@ var _ = BeforeSuite(func() {
 	err = usersv1alpha1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
 
+	err = usersv1beta1.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
+
 	//+kubebuilder:scaffold:scheme
 
 	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme.Scheme})
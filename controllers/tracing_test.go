@@ -0,0 +1,74 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestReconcileIsTraced(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		// Tracers already delegated to provider stop recording once it is
+		// shut down
+		_ = provider.Shutdown(context.Background())
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	var traceparent string
+	r := newTestReconciler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"7","createdAt":"2022-11-20T10:00:00.000Z"}`))
+	}), newTestUser())
+	reconcileUser(t, r, types.NamespacedName{Name: "janet", Namespace: "default"})
+
+	byName := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range exporter.GetSpans().Snapshots() {
+		byName[span.Name()] = span
+	}
+	for child, parent := range map[string]string{
+		"reqres create": "createUser",
+		"createUser":    "Reconcile",
+	} {
+		if byName[child] == nil || byName[parent] == nil {
+			t.Fatalf("missing span %q or %q, got %v", child, parent, byName)
+		}
+		if byName[child].Parent().SpanID() != byName[parent].SpanContext().SpanID() {
+			t.Errorf("span %q is not a child of %q", child, parent)
+		}
+	}
+	if byName["Reconcile"].Parent().IsValid() {
+		t.Errorf("Reconcile span should be a root span")
+	}
+	if traceparent == "" {
+		t.Errorf("trace context was not propagated to the backend")
+	}
+}
@@ -18,21 +18,41 @@ package controllers
 
 import (
 	"context"
-	"reflect"
-	"sort"
+	goerrors "errors"
+	"fmt"
+	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
-	usersv1alpha1 "github.com/adrafiq/reqres-controller/api/v1alpha1"
+	usersv1beta1 "github.com/adrafiq/reqres-controller/api/v1beta1"
+	"github.com/adrafiq/reqres-controller/pkg/backend"
 	reqres "github.com/adrafiq/reqres-controller/pkg/reqres"
 	"github.com/go-logr/logr"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // USERReconciler reconciles a USER object
@@ -40,16 +60,54 @@ type USERReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
 	Config *viper.Viper
+	// Backends holds the backends users can be managed in.
+	Backends *backend.Registry
+	// ReqresClients is optional and holds the clients of the ReqresBackends
+	// USERs select through spec.backendRef.
+	ReqresClients *ReqresClients
+	// Recorder is optional and records an event for every backend mutation
+	// and failure.
+	Recorder record.EventRecorder
+	// Metadata is optional and serves the metadata of ConfigMaps, Secrets
+	// and Namespaces, i.e. it is the manager's cache. With it, avatarFrom
+	// images are only read again once their metadata changed. Namespaces are
+	// read as metadata only, as their backend annotation is all USERs need,
+	// so that no full Namespace objects are cached.
+	Metadata client.Reader
+
+	avatars avatarCache
 }
 
 const (
-	notInitialized = 0
-	ctrlFinalizer  = "users.reqres.in/v1alpha1"
+	// ctrlFinalizer keeps its original name so that existing objects are
+	// still finalized.
+	ctrlFinalizer = "users.reqres.in/v1alpha1"
+	// createIntentAnnotation is set, holding the idempotency key, before the
+	// backend user is created and removed once its id is in status. Finding
+	// it on an object without id means a create may have been lost.
+	createIntentAnnotation = "users.reqres.in/create-intent"
 )
 
+// tracer starts the spans of reconciles.
+var tracer = otel.Tracer("github.com/adrafiq/reqres-controller/controllers")
+
+// statusBackoff paces retries of status writes that must not be lost.
+var statusBackoff = wait.Backoff{
+	Steps:    8,
+	Duration: 100 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+	Cap:      10 * time.Second,
+}
+
 //+kubebuilder:rbac:groups=users.reqres.in,resources=users,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=users.reqres.in,resources=users/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=users.reqres.in,resources=users/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups="",resources=configmaps;secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups=users.reqres.in,resources=reqresbackends,verbs=get;list;watch
+//+kubebuilder:rbac:groups=users.reqres.in,resources=backendprofiles,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -61,136 +119,740 @@ const (
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.13.0/pkg/reconcile
 func (r *USERReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracer.Start(ctx, "Reconcile", trace.WithAttributes(
+		attribute.String("k8s.namespace.name", req.Namespace),
+		attribute.String("reqres.user.name", req.Name),
+	))
+	defer span.End()
+	result, err := r.reconcile(ctx, req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return result, err
+}
+
+func (r *USERReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
-	userCR := &usersv1alpha1.USER{}
-	reqresURL := r.Config.GetString("REQRES_ROOT_URL")
-	client := reqres.NewClient(reqresURL, &logger)
+	userCR := &usersv1beta1.USER{}
 	err := r.Get(ctx, req.NamespacedName, userCR)
 	if err != nil && errors.IsNotFound(err) {
 		logger.Info("Object Deleted")
+		r.avatars.forget(req.NamespacedName)
 		return ctrl.Result{}, nil
 	} else if err != nil {
 		logger.Error(err, "Error getting operator resource object")
 		return ctrl.Result{}, err
 	}
 
-	// If deleted, http delete and remove finalizer
+	base := userCR.Status.DeepCopy()
+	result, err := r.sync(ctx, userCR, &logger)
+	if err := r.patchStatus(ctx, userCR, base); client.IgnoreNotFound(err) != nil {
+		logger.Error(err, "unable to update status")
+		return ctrl.Result{}, err
+	}
+	if _, ok := userCR.Annotations[createIntentAnnotation]; ok && userCR.Status.Created() {
+		// Harmless if it fails, the annotation is only read while no id is recorded
+		if err := r.patchCreateIntent(ctx, userCR, ""); err != nil {
+			logger.Info("unable to remove create intent", "error", err.Error())
+		}
+	}
+	return result, err
+}
+
+// sync drives the backend user towards userCR and records the outcome in
+// its status, which the caller persists.
+func (r *USERReconciler) sync(ctx context.Context, userCR *usersv1beta1.USER, logger *logr.Logger) (ctrl.Result, error) {
+	// If deleted, handle the backend user per deletion policy and remove finalizer
 	if userCR.ObjectMeta.DeletionTimestamp != nil {
-		client.DeleteUser(userCR.Status.Id)
-		if err != nil {
-			logger.Error(err, "http client error")
-			return ctrl.Result{Requeue: true}, nil
-		}
-		finalizers := userCR.ObjectMeta.Finalizers
-		idx := sort.SearchStrings(finalizers, ctrlFinalizer)
-		finalizers = append(finalizers[:idx], finalizers[idx+1:]...)
-		userCR.Finalizers = finalizers
-		r.Update(ctx, userCR)
-		return ctrl.Result{}, nil
+		r.avatars.forget(client.ObjectKeyFromObject(userCR))
+		return r.deleteUser(ctx, userCR, logger)
+	}
+
+	client, err := r.backendFor(ctx, userCR)
+	if unresolvedBackend(err) {
+		return r.unknownBackend(userCR, err, logger)
+	} else if err != nil {
+		logger.Error(err, "unable to select backend")
+		return ctrl.Result{}, err
+	}
+
+	// Register the finalizer before any backend user can exist
+	if !controllerutil.ContainsFinalizer(userCR, ctrlFinalizer) {
+		if err := r.patchFinalizers(ctx, userCR, controllerutil.AddFinalizer); err != nil {
+			logger.Error(err, "unable to add finalizer")
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Leave the backend alone until the next sync is due
+	avatar, avatarHash, avatarErr := r.desiredAvatar(ctx, userCR)
+	if remaining := r.untilSync(userCR, avatarHash); avatarErr == nil && remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+	userCR.Status.ObservedGeneration = userCR.Generation
+	userCR.Status.LastHandledSyncRequest = userCR.Annotations[usersv1beta1.SyncRequestAnnotation]
+	if avatarErr != nil {
+		return r.avatarUnavailable(userCR, avatarErr, logger)
+	}
+	userCR.Status.AvatarHash = avatarHash
+	desired := reqres.User{
+		Email:     userCR.Spec.Email,
+		FirstName: userCR.Spec.Name.First,
+		LastName:  userCR.Spec.Name.Last,
+		Avatar:    avatar,
+	}
+
+	// Skip the backend entirely while it is known to be failing
+	if retryAfter := backendRetryAfter(client); retryAfter > 0 {
+		return r.backendUnavailable(ctx, userCR, retryAfter, logger)
 	}
 
 	// Create user in backend, if not exists
-	if userCR.Status.Id == notInitialized {
-		return r.createUser(ctx, userCR, &client, &logger)
+	if !userCR.Status.Created() {
+		if userCR.Spec.ImportID != "" {
+			return r.importUser(ctx, userCR, client, logger)
+		}
+		if !userCR.Spec.ManagementPolicy.CanCreate() {
+			return r.nothingToObserve(ctx, userCR, logger)
+		}
+		return r.createUser(ctx, userCR, client, desired, logger)
 	}
-	return r.updateUser(ctx, userCR, &client, &logger)
+	return r.updateUser(ctx, userCR, client, desired, logger)
 }
 
-func (r *USERReconciler) createUser(ctx context.Context, userCR *usersv1alpha1.USER, client *reqres.Client, logger *logr.Logger) (ctrl.Result, error) {
-	user := reqres.User{
-		Email:     userCR.Spec.Email,
-		FirstName: userCR.Spec.FirstName,
-		LastName:  userCR.Spec.LastName,
+// deleteUser deletes or keeps the backend user according to the deletion
+// policy. The finalizer is only removed once that has succeeded. The backend
+// is only required for deleting a user known to exist, so that USERs whose
+// backend is gone can still be deleted when they keep their backend user.
+func (r *USERReconciler) deleteUser(ctx context.Context, userCR *usersv1beta1.USER, logger *logr.Logger) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(userCR, ctrlFinalizer) {
+		return ctrl.Result{}, nil
 	}
-	userCreated, err := client.CreateUser(user)
-	if err != nil {
-		logger.Error(err, "http client error")
-		return ctrl.Result{Requeue: true}, nil
+	policy := userCR.Spec.DeletionPolicy
+	deletesBackend := policy.DeletesBackend() && userCR.Spec.ManagementPolicy.CanDelete()
+	userCR.Status.ObservedGeneration = userCR.Generation
+	if deletesBackend {
+		setCondition(userCR, usersv1beta1.ConditionDeleting, metav1.ConditionTrue, usersv1beta1.ReasonDeletingBackend, "deleting backend user")
+	} else {
+		setCondition(userCR, usersv1beta1.ConditionDeleting, metav1.ConditionTrue, usersv1beta1.ReasonOrphaning, "keeping backend user per deletionPolicy and managementPolicy")
 	}
-	userStatus := usersv1alpha1.USERStatus{
-		Id: userCreated.Id,
-		Conditions: []metav1.Condition{{
-			Type:               "Available",
-			Status:             metav1.ConditionTrue,
-			LastTransitionTime: metav1.NewTime(time.Now()),
-			Reason:             "OperatorSucceeded",
-			Message:            "user successfully created",
-		}},
+	_, interrupted := userCR.Annotations[createIntentAnnotation]
+	var client backend.UserBackend
+	if deletesBackend && (userCR.Status.Created() || interrupted) {
+		var err error
+		client, err = r.backendFor(ctx, userCR)
+		if unresolvedBackend(err) && userCR.Status.Created() {
+			return r.unknownBackend(userCR, err, logger)
+		} else if unresolvedBackend(err) {
+			logger.Info("unable to look for a backend user of the interrupted create", "error", err.Error())
+		} else if err != nil {
+			logger.Error(err, "unable to select backend")
+			return ctrl.Result{}, err
+		}
 	}
-	userCR.Status = userStatus
-	if err := r.Status().Update(ctx, userCR); err != nil {
-		logger.Info("unable to update status")
+	var id string
+	if userCR.Status.Created() {
+		id = *userCR.Status.ExternalID
+	} else if client != nil {
+		// The user may have been created without its id being recorded
+		existing, err := reqres.FindUserByEmail(ctx, client, userCR.Spec.Email)
+		if err != nil && !goerrors.Is(err, reqres.ErrNotFound) {
+			return r.syncFailed(ctx, userCR, "", err, logger)
+		} else if err == nil {
+			id = existing.Id
+		}
 	}
+	if id == "" {
+		logger.Info("no backend user to delete")
+	} else if !userCR.Spec.ManagementPolicy.CanDelete() {
+		logger.Info("keeping backend user", "id", id, "managementPolicy", userCR.Spec.ManagementPolicy)
+	} else if !policy.DeletesBackend() {
+		logger.Info("keeping backend user", "id", id, "deletionPolicy", policy)
+	} else {
+		if retryAfter := backendRetryAfter(client); retryAfter > 0 {
+			return r.backendUnavailable(ctx, userCR, retryAfter, logger)
+		}
+		_, err := client.DeleteUser(ctx, id)
+		if goerrors.Is(err, reqres.ErrNotFound) {
+			err = nil
+		}
+		recordOperation(reqres.OpDelete, err)
+		if err != nil {
+			return r.syncFailed(ctx, userCR, id, err, logger)
+		}
+		logger.Info("deleted backend user", "id", id)
+		r.recordEvent(userCR, corev1.EventTypeNormal, ReasonDeleted, id, "deleted backend user %s", id)
+	}
+	if err := r.patchFinalizers(ctx, userCR, controllerutil.RemoveFinalizer); err != nil {
+		logger.Error(err, "unable to remove finalizer")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// patchFinalizers applies mutate, e.g. controllerutil.AddFinalizer, and
+// patches the result. The optimistic lock keeps finalizers owned by others.
+func (r *USERReconciler) patchFinalizers(ctx context.Context, userCR *usersv1beta1.USER, mutate func(client.Object, string) bool) error {
+	patch := client.MergeFromWithOptions(userCR.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	if !mutate(userCR, ctrlFinalizer) {
+		return nil
+	}
+	return r.patchMetadata(ctx, userCR, patch)
+}
+
+// createUser creates the backend user. The create intent is persisted first,
+// so that a create whose id never reached status is found again by email
+// instead of being repeated.
+func (r *USERReconciler) createUser(ctx context.Context, userCR *usersv1beta1.USER, client backend.UserBackend, user reqres.User, logger *logr.Logger) (ctrl.Result, error) {
+	ctx, span := tracer.Start(ctx, "createUser")
+	defer span.End()
+	key, interrupted := userCR.Annotations[createIntentAnnotation]
+	if interrupted {
+		existing, err := reqres.FindUserByEmail(ctx, client, userCR.Spec.Email)
+		if err != nil && !goerrors.Is(err, reqres.ErrNotFound) {
+			return r.syncFailed(ctx, userCR, "", err, logger)
+		} else if err == nil {
+			logger.Info("found backend user of interrupted create", "id", existing.Id)
+			r.recordEvent(userCR, corev1.EventTypeNormal, ReasonCreated, existing.Id, "recovered backend user %s created by an interrupted attempt", existing.Id)
+			return userCreated(userCR, existing, "backend user recovered after interrupted create")
+		}
+	} else {
+		key = string(uuid.NewUUID())
+		if err := r.patchCreateIntent(ctx, userCR, key); err != nil {
+			logger.Error(err, "unable to record create intent")
+			return ctrl.Result{}, err
+		}
+	}
+	created, err := client.CreateUser(ctx, user, key)
+	recordOperation(reqres.OpCreate, err)
+	if err != nil {
+		return r.syncFailed(ctx, userCR, "", err, logger)
+	}
+	r.recordEvent(userCR, corev1.EventTypeNormal, ReasonCreated, created.Id, "created backend user %s", created.Id)
+	// The create response may carry no more than the id, so the user that
+	// was sent is observed until the first GET.
+	user.Id = created.Id
+	return userCreated(userCR, &user, "backend user created")
+}
+
+// userCreated records the id of a created backend user. The create intent
+// is dropped once that reached the status.
+func userCreated(userCR *usersv1beta1.USER, user *reqres.User, message string) (ctrl.Result, error) {
+	userCR.Status.ExternalID = &user.Id
+	userCR.Status.AtProvider = observation(user)
+	userCR.Status.DriftedFields = nil
+	synced(userCR, message)
+	setCondition(userCR, usersv1beta1.ConditionReady, metav1.ConditionTrue, usersv1beta1.ReasonAvailable, message)
 	return ctrl.Result{}, nil
 }
 
-func (r *USERReconciler) updateUser(ctx context.Context, userCR *usersv1alpha1.USER, client *reqres.Client, logger *logr.Logger) (ctrl.Result, error) {
-	var userStatus usersv1alpha1.USERStatus
-	user, err := client.GetUser(userCR.Status.Id)
-	if err != nil && err.Error() == "error making http request" {
-		logger.Error(err, "http client error")
+// patchCreateIntent sets the create intent annotation to key, or removes it
+// if key is empty.
+func (r *USERReconciler) patchCreateIntent(ctx context.Context, userCR *usersv1beta1.USER, key string) error {
+	patch := client.MergeFromWithOptions(userCR.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	annotations := userCR.GetAnnotations()
+	if key == "" {
+		if _, ok := annotations[createIntentAnnotation]; !ok {
+			return nil
+		}
+		delete(annotations, createIntentAnnotation)
+	} else {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[createIntentAnnotation] = key
+	}
+	userCR.SetAnnotations(annotations)
+	return r.patchMetadata(ctx, userCR, patch)
+}
+
+// patchMetadata applies patch to the object, keeping the status computed so
+// far instead of the stored one the response carries.
+func (r *USERReconciler) patchMetadata(ctx context.Context, userCR *usersv1beta1.USER, patch client.Patch) error {
+	status := userCR.Status.DeepCopy()
+	err := r.Patch(ctx, userCR, patch)
+	status.DeepCopyInto(&userCR.Status)
+	return err
+}
+
+// patchStatus writes the status of userCR with a merge patch, unless it is
+// unchanged from base. Writes are retried on any error but the object being
+// gone, as losing one that records a created backend user would leave that
+// user unmanaged.
+func (r *USERReconciler) patchStatus(ctx context.Context, userCR *usersv1beta1.USER, base *usersv1beta1.USERStatus) error {
+	if equality.Semantic.DeepEqual(*base, userCR.Status) {
+		return nil
+	}
+	for _, conditionType := range legacyConditions {
+		meta.RemoveStatusCondition(&userCR.Status.Conditions, conditionType)
+	}
+	original := userCR.DeepCopy()
+	original.Status = *base
+	patch := client.MergeFrom(original)
+	return retry.OnError(statusBackoff, func(err error) bool {
+		return !errors.IsNotFound(err) && ctx.Err() == nil
+	}, func() error {
+		return r.Status().Patch(ctx, userCR, patch)
+	})
+}
+
+// importUser adopts an existing backend user instead of creating a new one.
+// Once the status carries its id, the user is managed like any other.
+func (r *USERReconciler) importUser(ctx context.Context, userCR *usersv1beta1.USER, client backend.UserBackend, logger *logr.Logger) (ctrl.Result, error) {
+	user, err := client.GetUser(ctx, userCR.Spec.ImportID)
+	if err != nil && !goerrors.Is(err, reqres.ErrNotFound) {
+		return r.syncFailed(ctx, userCR, userCR.Spec.ImportID, err, logger)
+	} else if err != nil {
+		logger.Error(err, "unable to find user to import in backend", "importID", userCR.Spec.ImportID)
+		r.recordEvent(userCR, corev1.EventTypeWarning, ReasonValidationFailed, "", "no backend user %s to import", userCR.Spec.ImportID)
+		message := "could not find user " + userCR.Spec.ImportID + " to import in backend"
+		setCondition(userCR, usersv1beta1.ConditionReady, metav1.ConditionFalse, usersv1beta1.ReasonImportNotFound, message)
+		setCondition(userCR, usersv1beta1.ConditionSynced, metav1.ConditionFalse, usersv1beta1.ReasonImportNotFound, message)
 		return ctrl.Result{Requeue: true}, nil
+	}
+	userCR.Status.ExternalID = &user.Id
+	userCR.Status.AtProvider = observation(user)
+	userCR.Status.DriftedFields = nil
+	synced(userCR, "backend user imported")
+	setCondition(userCR, usersv1beta1.ConditionReady, metav1.ConditionTrue, usersv1beta1.ReasonAvailable, "backend user imported")
+	logger.Info("imported existing backend user", "id", user.Id)
+	r.recordEvent(userCR, corev1.EventTypeNormal, ReasonImported, user.Id, "imported backend user %s", user.Id)
+	return ctrl.Result{}, nil
+}
+
+func (r *USERReconciler) updateUser(ctx context.Context, userCR *usersv1beta1.USER, client backend.UserBackend, desired reqres.User, logger *logr.Logger) (ctrl.Result, error) {
+	ctx, span := tracer.Start(ctx, "updateUser", trace.WithAttributes(
+		attribute.String("reqres.user.id", *userCR.Status.ExternalID),
+	))
+	defer span.End()
+	id := *userCR.Status.ExternalID
+	user, err := client.GetUser(ctx, id)
+	if err != nil && !goerrors.Is(err, reqres.ErrNotFound) {
+		return r.syncFailed(ctx, userCR, id, err, logger)
 	} else if err != nil {
 		logger.Error(err, "unable to find user in backend")
-		userStatus = usersv1alpha1.USERStatus{
-			Id: 0,
-			Conditions: []metav1.Condition{{
-				Type:               "Unavailable",
-				Status:             metav1.ConditionUnknown,
-				LastTransitionTime: metav1.NewTime(time.Now()),
-				Reason:             "OperatorSucceeded",
-				Message:            "could not find user in backend",
-			}},
-		}
-		userCR.Status = userStatus
-		if err := r.Status().Update(ctx, userCR); err != nil {
-			logger.Info("unable to update status")
-		}
+		r.recordEvent(userCR, corev1.EventTypeWarning, ReasonDriftDetected, id, "backend user %s no longer exists", id)
+		// Forget the user, so that it is created again
+		userCR.Status.ExternalID = nil
+		userCR.Status.AtProvider = nil
+		userCR.Status.DriftedFields = nil
+		message := "backend user " + id + " no longer exists"
+		setCondition(userCR, usersv1beta1.ConditionReady, metav1.ConditionFalse, usersv1beta1.ReasonBackendNotFound, message)
+		setCondition(userCR, usersv1beta1.ConditionSynced, metav1.ConditionFalse, usersv1beta1.ReasonBackendNotFound, message)
 		return ctrl.Result{Requeue: true}, nil
 	}
-	userStatus = usersv1alpha1.USERStatus{
-		Id: user.Id,
-		Conditions: []metav1.Condition{{
-			Type:               "Available",
-			Status:             metav1.ConditionTrue,
-			LastTransitionTime: metav1.NewTime(time.Now()),
-			Reason:             "OperatorSucceeded",
-			Message:            "user successfully synced",
-		}},
-	}
-	userFromCR := reqres.User{
-		Email:     userCR.Spec.Email,
-		FirstName: userCR.Spec.FirstName,
-		LastName:  userCR.Spec.LastName,
+	userCR.Status.AtProvider = observation(user)
+	diff := reqres.Compare(desired, *user)
+	drifted := driftedSpecFields(diff)
+	userCR.Status.DriftedFields = drifted
+	for _, field := range drifted {
+		driftTotal.WithLabelValues(field).Inc()
+	}
+	if len(drifted) > 0 {
+		r.recordEvent(userCR, corev1.EventTypeWarning, ReasonDriftDetected, id, "backend user %s differs from spec in %s", id, strings.Join(drifted, ", "))
 	}
-	if !reflect.DeepEqual(user, userFromCR) {
+	if len(drifted) == 0 {
+		synced(userCR, "backend user observed")
+		setCondition(userCR, usersv1beta1.ConditionReady, metav1.ConditionTrue, usersv1beta1.ReasonAvailable, "backend user matches spec")
+	} else if !userCR.Spec.ManagementPolicy.CanUpdate() {
+		logger.Info("backend user differs from spec, not updating", "fields", drifted, "managementPolicy", userCR.Spec.ManagementPolicy)
+		synced(userCR, "backend user observed")
+		setCondition(userCR, usersv1beta1.ConditionReady, metav1.ConditionTrue, usersv1beta1.ReasonDrifted,
+			"backend user differs from spec in "+strings.Join(drifted, ", ")+", not updated per managementPolicy")
+	} else if !userCR.Spec.DriftPolicy.Corrects() {
+		logger.Info("backend user differs from spec, reporting only", "fields", drifted, "driftPolicy", userCR.Spec.DriftPolicy)
+		synced(userCR, "backend user observed")
+		setCondition(userCR, usersv1beta1.ConditionReady, metav1.ConditionTrue, usersv1beta1.ReasonDrifted,
+			"backend user differs from spec in "+strings.Join(drifted, ", ")+", reported only per driftPolicy")
+	} else {
 		// Patch User
-		err := client.UpdateUser(*&userFromCR)
+		logger.Info("backend user differs from spec, updating", "fields", drifted)
+		err := client.PatchUser(ctx, id, diff)
+		recordOperation(reqres.OpUpdate, err)
 		if err != nil {
-			logger.Error(err, "error making http request")
-			return ctrl.Result{Requeue: true}, nil
+			return r.syncFailed(ctx, userCR, id, err, logger)
 		}
-		userStatus = usersv1alpha1.USERStatus{
-			Id: user.Id,
-			Conditions: []metav1.Condition{{
-				Type:               "Available",
-				Status:             metav1.ConditionTrue,
-				LastTransitionTime: metav1.NewTime(time.Now()),
-				Reason:             "OperatorSucceeded",
-				Message:            "user successfully updated",
-			}},
+		r.recordEvent(userCR, corev1.EventTypeNormal, ReasonUpdated, id, "updated %s of backend user %s", strings.Join(drifted, ", "), id)
+		updated := diff.Apply(*user)
+		userCR.Status.AtProvider = observation(&updated)
+		userCR.Status.DriftedFields = nil
+		synced(userCR, "backend user updated in "+strings.Join(drifted, ", "))
+		setCondition(userCR, usersv1beta1.ConditionReady, metav1.ConditionTrue, usersv1beta1.ReasonAvailable, "backend user matches spec")
+	}
+	return ctrl.Result{RequeueAfter: r.syncInterval(userCR)}, nil
+}
+
+// specFieldPaths maps the compared fields to their path in the USER spec,
+// where they differ.
+var specFieldPaths = map[string]string{
+	reqres.FieldFirstName: "name.first",
+	reqres.FieldLastName:  "name.last",
+}
+
+// driftedSpecFields names the fields of diff by their path in the USER spec.
+func driftedSpecFields(diff reqres.Diff) []string {
+	fields := diff.Fields()
+	for i, field := range fields {
+		if path, ok := specFieldPaths[field]; ok {
+			fields[i] = path
 		}
 	}
-	userCR.Status = userStatus
-	if err := r.Status().Update(ctx, userCR); err != nil {
-		logger.Info("unable to update status")
+	return fields
+}
+
+// syncInterval is how long until the backend user is checked for drift again.
+func (r *USERReconciler) syncInterval(userCR *usersv1beta1.USER) time.Duration {
+	if userCR.Spec.SyncInterval != nil && userCR.Spec.SyncInterval.Duration > 0 {
+		return userCR.Spec.SyncInterval.Duration
+	}
+	return r.Config.GetDuration("REQRES_SYNC_INTERVAL")
+}
+
+// untilSync is how long the last sync stays current, so that reconciles in
+// between, e.g. after a restart or on events of the object's own writes, are
+// answered from status without calling the backend. A new generation, a
+// sync request, a new spec.avatarFrom image or a failed sync make the next
+// sync due immediately.
+func (r *USERReconciler) untilSync(userCR *usersv1beta1.USER, avatarHash string) time.Duration {
+	status := userCR.Status
+	if !status.Created() || status.LastSyncTime == nil || status.AvatarHash != avatarHash ||
+		status.ObservedGeneration != userCR.Generation ||
+		status.LastHandledSyncRequest != userCR.Annotations[usersv1beta1.SyncRequestAnnotation] ||
+		!meta.IsStatusConditionTrue(status.Conditions, usersv1beta1.ConditionSynced) {
+		return 0
+	}
+	return time.Until(status.LastSyncTime.Add(r.syncInterval(userCR)))
+}
+
+// nothingToObserve reports an ObserveOnly user that has no backend user to
+// observe. It is retried once the spec changes.
+func (r *USERReconciler) nothingToObserve(ctx context.Context, userCR *usersv1beta1.USER, logger *logr.Logger) (ctrl.Result, error) {
+	logger.Info("observe only user without spec.importID, nothing to observe")
+	message := "managementPolicy ObserveOnly requires spec.importID"
+	setCondition(userCR, usersv1beta1.ConditionReady, metav1.ConditionFalse, usersv1beta1.ReasonImportIDRequired, message)
+	setCondition(userCR, usersv1beta1.ConditionSynced, metav1.ConditionFalse, usersv1beta1.ReasonImportIDRequired, message)
+	return ctrl.Result{}, nil
+}
+
+// observation mirrors a backend user into status.atProvider. Inlined avatars
+// are left out to keep the object small, status.avatarHash stands for them.
+func observation(user *reqres.User) *usersv1beta1.USERObservation {
+	avatar := user.Avatar
+	if strings.HasPrefix(avatar, "data:") {
+		avatar = ""
+	}
+	return &usersv1beta1.USERObservation{
+		Email: user.Email,
+		Name: usersv1beta1.UserName{
+			First: user.FirstName,
+			Last:  user.LastName,
+		},
+		Avatar: avatar,
+	}
+}
+
+// avatarUnavailable reports a spec.avatarFrom that does not resolve to an
+// image. The object is reconciled again once its source changes.
+func (r *USERReconciler) avatarUnavailable(userCR *usersv1beta1.USER, err error, logger *logr.Logger) (ctrl.Result, error) {
+	logger.Info("unable to resolve avatar", "error", err.Error())
+	var id string
+	if userCR.Status.Created() {
+		id = *userCR.Status.ExternalID
+	}
+	r.recordEvent(userCR, corev1.EventTypeWarning, ReasonValidationFailed, id, "%s", err)
+	setCondition(userCR, usersv1beta1.ConditionSynced, metav1.ConditionFalse, usersv1beta1.ReasonAvatarUnavailable, err.Error())
+	if !userCR.Status.Created() {
+		setCondition(userCR, usersv1beta1.ConditionReady, metav1.ConditionFalse, usersv1beta1.ReasonCreating, "backend user does not exist yet")
 	}
 	return ctrl.Result{}, nil
 }
 
+// legacyConditions were set by the v1alpha1 controller. They are dropped
+// with the first status written since, instead of lingering next to Ready.
+var legacyConditions = []string{"Available", "Unavailable"}
+
+// setCondition sets a condition observed at the current generation. Its
+// transition time only moves when its status changes.
+func setCondition(userCR *usersv1beta1.USER, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&userCR.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: userCR.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// synced records a successful exchange with the backend.
+func synced(userCR *usersv1beta1.USER, message string) {
+	now := metav1.Now()
+	userCR.Status.LastSyncTime = &now
+	setCondition(userCR, usersv1beta1.ConditionSynced, metav1.ConditionTrue, usersv1beta1.ReasonReconcileSuccess, message)
+	if meta.FindStatusCondition(userCR.Status.Conditions, usersv1beta1.ConditionBackendAvailable) != nil {
+		setCondition(userCR, usersv1beta1.ConditionBackendAvailable, metav1.ConditionTrue, usersv1beta1.ReasonAvailable, "backend answered")
+	}
+}
+
+// syncFailed reports a failed backend call in events and status, and
+// requeues per backendErrorResult. Ready is left alone for an existing user,
+// whose last known state still holds.
+func (r *USERReconciler) syncFailed(ctx context.Context, userCR *usersv1beta1.USER, id string, err error, logger *logr.Logger) (ctrl.Result, error) {
+	logger.Error(err, "http client error", "statusCode", reqres.StatusCode(err))
+	r.recordBackendError(userCR, id, err)
+	reason := usersv1beta1.ReasonBackendError
+	if goerrors.Is(err, reqres.ErrPermanent) {
+		reason = usersv1beta1.ReasonInvalidRequest
+	}
+	setCondition(userCR, usersv1beta1.ConditionSynced, metav1.ConditionFalse, reason, err.Error())
+	if !userCR.Status.Created() {
+		setCondition(userCR, usersv1beta1.ConditionReady, metav1.ConditionFalse, usersv1beta1.ReasonCreating, "backend user does not exist yet")
+	}
+	return backendErrorResult(err), nil
+}
+
+func (r *USERReconciler) backendUnavailable(ctx context.Context, userCR *usersv1beta1.USER, retryAfter time.Duration, logger *logr.Logger) (ctrl.Result, error) {
+	logger.Info("backend circuit open, skipping backend calls", "retryAfter", retryAfter.String())
+	var id string
+	if userCR.Status.Created() {
+		id = *userCR.Status.ExternalID
+	}
+	r.recordEvent(userCR, corev1.EventTypeWarning, ReasonBackendUnavailable, id, "backend is failing, calls are suspended for %s", retryAfter.Round(time.Second))
+	message := "backend is failing, calls are suspended until the circuit breaker cools down"
+	setCondition(userCR, usersv1beta1.ConditionSynced, metav1.ConditionFalse, usersv1beta1.ReasonCircuitOpen, message)
+	setCondition(userCR, usersv1beta1.ConditionBackendAvailable, metav1.ConditionFalse, usersv1beta1.ReasonCircuitOpen, message)
+	if !userCR.Status.Created() {
+		setCondition(userCR, usersv1beta1.ConditionReady, metav1.ConditionFalse, usersv1beta1.ReasonCreating, "backend user does not exist yet")
+	}
+	return ctrl.Result{RequeueAfter: retryAfter}, nil
+}
+
+// backendRetryAfter returns how long backend calls are suspended, if at all.
+func backendRetryAfter(client backend.UserBackend) time.Duration {
+	if suspender, ok := client.(backend.Suspender); ok {
+		return suspender.RetryAfter()
+	}
+	return 0
+}
+
+// backendFor returns the backend userCR is managed in and records its name
+// in status, "ReqresBackend/<name>" for a spec.backendRef. Once the user
+// exists, it stays in the backend it was created in.
+func (r *USERReconciler) backendFor(ctx context.Context, userCR *usersv1beta1.USER) (backend.UserBackend, error) {
+	name := userCR.Status.Backend
+	if !userCR.Status.Created() {
+		name = userCR.Annotations[usersv1beta1.BackendAnnotation]
+		if ref := userCR.Spec.BackendRef; ref != nil {
+			name = reqresBackendPrefix + ref.Name
+		} else if name == "" {
+			namespace := &metav1.PartialObjectMetadata{}
+			namespace.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Namespace"))
+			var reader client.Reader = r.Client
+			if r.Metadata != nil {
+				reader = r.Metadata
+			}
+			if err := reader.Get(ctx, client.ObjectKey{Name: userCR.Namespace}, namespace); client.IgnoreNotFound(err) != nil {
+				return nil, err
+			}
+			name = namespace.Annotations[usersv1beta1.BackendAnnotation]
+		}
+	}
+	if name == "" {
+		name = r.Backends.Default()
+	}
+	var selected backend.UserBackend
+	var err error
+	if ref := strings.TrimPrefix(name, reqresBackendPrefix); ref != name {
+		selected, err = r.reqresBackend(ctx, types.NamespacedName{Namespace: userCR.Namespace, Name: ref})
+	} else {
+		selected, err = r.Backends.Get(name)
+		if goerrors.Is(err, backend.ErrUnknownBackend) && r.profileExists(ctx, name) {
+			err = fmt.Errorf("%w: BackendProfile %q is not registered", errProfileNotRegistered, name)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	userCR.Status.Backend = name
+	return selected, nil
+}
+
+// reqresBackendPrefix marks backends in status.backend that are
+// ReqresBackends of the USER's namespace rather than registered ones.
+const reqresBackendPrefix = "ReqresBackend/"
+
+// errBackendNotReady is wrapped when a ReqresBackend exists but its client
+// cannot be built, e.g. as its Secret is missing.
+var errBackendNotReady = goerrors.New("backend not ready")
+
+// errProfileNotRegistered wraps errBackendNotReady when a BackendProfile
+// exists but is not registered, e.g. right after the controller started or
+// while it is invalid.
+var errProfileNotRegistered = fmt.Errorf("%w", errBackendNotReady)
+
+// profileExists reports whether a BackendProfile is named name.
+func (r *USERReconciler) profileExists(ctx context.Context, name string) bool {
+	return r.Get(ctx, client.ObjectKey{Name: name}, &usersv1beta1.BackendProfile{}) == nil
+}
+
+// reqresBackend returns the cached client of a ReqresBackend. The error
+// wraps backend.ErrUnknownBackend if there is no such backend.
+func (r *USERReconciler) reqresBackend(ctx context.Context, key types.NamespacedName) (backend.UserBackend, error) {
+	backendCR := &usersv1beta1.ReqresBackend{}
+	if err := r.Get(ctx, key, backendCR); errors.IsNotFound(err) || (err == nil && r.ReqresClients == nil) {
+		return nil, fmt.Errorf("%w: ReqresBackend %q", backend.ErrUnknownBackend, key.Name)
+	} else if err != nil {
+		return nil, err
+	}
+	selected, err := r.ReqresClients.Get(ctx, r.Client, backendCR)
+	if err != nil {
+		return nil, fmt.Errorf("%w: ReqresBackend %q: %v", errBackendNotReady, key.Name, err)
+	}
+	return selected, nil
+}
+
+// unresolvedBackend reports whether err means the backend of a USER is not
+// registered, or its ReqresBackend is missing or not ready.
+func unresolvedBackend(err error) bool {
+	return goerrors.Is(err, backend.ErrUnknownBackend) || goerrors.Is(err, errBackendNotReady)
+}
+
+// unknownBackend reports a USER selecting a backend that is not registered,
+// or a ReqresBackend that is missing or not ready. It is retried once per
+// sync interval, as the namespace's annotation is not watched; changes to a
+// ReqresBackend or BackendProfile requeue its USERs right away. A profile
+// about to be registered is retried with backoff instead, as its USERs may
+// be reconciled first.
+func (r *USERReconciler) unknownBackend(userCR *usersv1beta1.USER, err error, logger *logr.Logger) (ctrl.Result, error) {
+	logger.Info("unable to select backend", "error", err.Error(), "registered", r.Backends.Names())
+	var id string
+	if userCR.Status.Created() {
+		id = *userCR.Status.ExternalID
+	}
+	pending := goerrors.Is(err, errProfileNotRegistered)
+	if !pending {
+		r.recordEvent(userCR, corev1.EventTypeWarning, ReasonValidationFailed, id, "%s", err)
+	}
+	userCR.Status.ObservedGeneration = userCR.Generation
+	reason := usersv1beta1.ReasonUnknownBackend
+	if goerrors.Is(err, errBackendNotReady) {
+		reason = usersv1beta1.ReasonBackendNotReady
+	}
+	setCondition(userCR, usersv1beta1.ConditionSynced, metav1.ConditionFalse, reason, err.Error())
+	if !userCR.Status.Created() {
+		setCondition(userCR, usersv1beta1.ConditionReady, metav1.ConditionFalse, usersv1beta1.ReasonCreating, "backend user does not exist yet")
+	}
+	if pending {
+		return ctrl.Result{Requeue: true}, nil
+	}
+	return ctrl.Result{RequeueAfter: r.syncInterval(userCR)}, nil
+}
+
+// backendErrorResult requeues after the delay requested by the backend, if
+// any, and otherwise leaves the backoff to the workqueue's rate limiter.
+func backendErrorResult(err error) ctrl.Result {
+	var apiErr *reqres.APIError
+	if goerrors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return ctrl.Result{RequeueAfter: apiErr.RetryAfter}
+	}
+	var openErr *reqres.CircuitOpenError
+	if goerrors.As(err, &openErr) && openErr.RetryAfter > 0 {
+		return ctrl.Result{RequeueAfter: openErr.RetryAfter}
+	}
+	return ctrl.Result{Requeue: true}
+}
+
+// userPredicates ignore updates that only touch status or other metadata,
+// foremost the controller's own status writes. Periodic syncs are driven by
+// requeues instead.
+var userPredicates = predicate.Or(
+	predicate.GenerationChangedPredicate{},
+	predicate.AnnotationChangedPredicate{},
+)
+
+// backendRefIndex indexes USERs by the ReqresBackend their spec.backendRef
+// selects.
+const backendRefIndex = "spec.backendRef"
+
+func backendRefs(obj client.Object) []string {
+	userCR, ok := obj.(*usersv1beta1.USER)
+	if !ok || userCR.Spec.BackendRef == nil {
+		return nil
+	}
+	return []string{userCR.Spec.BackendRef.Name}
+}
+
+// usersForReqresBackend maps a ReqresBackend to the USERs selecting it, so
+// that they retry once it became ready.
+func (r *USERReconciler) usersForReqresBackend(obj client.Object) []reconcile.Request {
+	users := &usersv1beta1.USERList{}
+	if err := r.List(context.Background(), users,
+		client.InNamespace(obj.GetNamespace()),
+		client.MatchingFields{backendRefIndex: obj.GetName()},
+	); err != nil {
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(users.Items))
+	for _, user := range users.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&user)})
+	}
+	return requests
+}
+
+// selectedBackendIndex indexes USERs by the name of the registered backend
+// they select themselves: status.backend once the backend user exists, and
+// otherwise their annotation, empty if the namespace or default decides.
+const selectedBackendIndex = "backend"
+
+func selectedBackends(obj client.Object) []string {
+	userCR, ok := obj.(*usersv1beta1.USER)
+	if !ok {
+		return nil
+	}
+	if userCR.Status.Created() {
+		return []string{userCR.Status.Backend}
+	}
+	if userCR.Spec.BackendRef != nil {
+		return nil
+	}
+	return []string{userCR.Annotations[usersv1beta1.BackendAnnotation]}
+}
+
+// usersForBackendProfile maps a BackendProfile to the USERs that may select
+// it, including those going by their namespace's annotation, so that they
+// retry once it is registered.
+func (r *USERReconciler) usersForBackendProfile(obj client.Object) []reconcile.Request {
+	var requests []reconcile.Request
+	for _, name := range []string{obj.GetName(), ""} {
+		users := &usersv1beta1.USERList{}
+		if err := r.List(context.Background(), users, client.MatchingFields{selectedBackendIndex: name}); err != nil {
+			return nil
+		}
+		for _, user := range users.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&user)})
+		}
+	}
+	return requests
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *USERReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &usersv1beta1.USER{}, avatarSourceIndex, avatarSources); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &usersv1beta1.USER{}, backendRefIndex, backendRefs); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &usersv1beta1.USER{}, selectedBackendIndex, selectedBackends); err != nil {
+		return err
+	}
+	// Only the metadata of ConfigMaps and Secrets is cached, to learn of
+	// changes. Their contents are read once changed, see main.
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&usersv1alpha1.USER{}).
+		For(&usersv1beta1.USER{}, builder.WithPredicates(userPredicates)).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(r.usersForAvatarSource("ConfigMap")), builder.OnlyMetadata).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.usersForAvatarSource("Secret")), builder.OnlyMetadata).
+		Watches(&source.Kind{Type: &usersv1beta1.ReqresBackend{}}, handler.EnqueueRequestsFromMapFunc(r.usersForReqresBackend)).
+		Watches(&source.Kind{Type: &usersv1beta1.BackendProfile{}}, handler.EnqueueRequestsFromMapFunc(r.usersForBackendProfile)).
 		Complete(r)
 }
@@ -0,0 +1,157 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command dashboard writes a Grafana dashboard for the metrics exported by
+// the controller to stdout. Run it through `make dashboard`.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/adrafiq/reqres-controller/controllers"
+	"github.com/adrafiq/reqres-controller/pkg/reqres"
+)
+
+type target struct {
+	Expr   string
+	Legend string
+}
+
+type panel struct {
+	Title   string
+	Unit    string
+	Targets []target
+}
+
+// panels derive their queries from the metric names the controller exports,
+// so renaming a metric breaks the build instead of the dashboard.
+var panels = []panel{
+	{
+		Title: "Backend request latency",
+		Unit:  "s",
+		Targets: []target{
+			{fmt.Sprintf(`histogram_quantile(0.5, sum by (le, operation) (rate(%s_bucket[5m])))`, reqres.MetricRequestDuration), "p50 {{operation}}"},
+			{fmt.Sprintf(`histogram_quantile(0.95, sum by (le, operation) (rate(%s_bucket[5m])))`, reqres.MetricRequestDuration), "p95 {{operation}}"},
+			{fmt.Sprintf(`histogram_quantile(0.99, sum by (le, operation) (rate(%s_bucket[5m])))`, reqres.MetricRequestDuration), "p99 {{operation}}"},
+		},
+	},
+	{
+		Title: "Backend requests by status code",
+		Unit:  "reqps",
+		Targets: []target{
+			{fmt.Sprintf(`sum by (operation, code) (rate(%s_count[5m]))`, reqres.MetricRequestDuration), "{{operation}} {{code}}"},
+		},
+	},
+	{
+		Title: "Backend retries",
+		Unit:  "reqps",
+		Targets: []target{
+			{fmt.Sprintf(`sum by (operation, reason) (rate(%s[5m]))`, reqres.MetricRetries), "{{operation}} {{reason}}"},
+		},
+	},
+	{
+		Title: "Circuit breaker state (0 closed, 1 open, 2 half-open)",
+		Unit:  "none",
+		Targets: []target{
+			{fmt.Sprintf(`max by (namespace, backend) (%s)`, reqres.MetricCircuitState), "{{namespace}} {{backend}}"},
+		},
+	},
+	{
+		Title: "User operations",
+		Unit:  "ops",
+		Targets: []target{
+			{fmt.Sprintf(`sum by (operation, outcome) (rate(%s[5m]))`, controllers.MetricOperations), "{{operation}} {{outcome}}"},
+		},
+	},
+	{
+		Title: "Users by condition",
+		Unit:  "none",
+		Targets: []target{
+			{fmt.Sprintf(`sum by (condition, status) (%s)`, controllers.MetricUsers), "{{condition}}={{status}}"},
+		},
+	},
+	{
+		Title: "Drift detected",
+		Unit:  "none",
+		Targets: []target{
+			{fmt.Sprintf(`sum by (field) (increase(%s[1h]))`, controllers.MetricDrift), "{{field}}"},
+		},
+	},
+}
+
+const (
+	panelWidth  = 12
+	panelHeight = 8
+)
+
+func main() {
+	datasource := map[string]string{"type": "prometheus", "uid": "${datasource}"}
+	grafanaPanels := make([]map[string]interface{}, 0, len(panels))
+	for i, p := range panels {
+		targets := make([]map[string]interface{}, 0, len(p.Targets))
+		for j, t := range p.Targets {
+			targets = append(targets, map[string]interface{}{
+				"datasource":   datasource,
+				"expr":         t.Expr,
+				"legendFormat": t.Legend,
+				"refId":        string(rune('A' + j)),
+			})
+		}
+		grafanaPanels = append(grafanaPanels, map[string]interface{}{
+			"id":         i + 1,
+			"type":       "timeseries",
+			"title":      p.Title,
+			"datasource": datasource,
+			"gridPos": map[string]int{
+				"x": (i % 2) * panelWidth,
+				"y": (i / 2) * panelHeight,
+				"w": panelWidth,
+				"h": panelHeight,
+			},
+			"fieldConfig": map[string]interface{}{
+				"defaults":  map[string]string{"unit": p.Unit},
+				"overrides": []interface{}{},
+			},
+			"targets": targets,
+		})
+	}
+	dashboard := map[string]interface{}{
+		"title":         "reqres-controller",
+		"uid":           "reqres-controller",
+		"schemaVersion": 36,
+		"refresh":       "30s",
+		"time":          map[string]string{"from": "now-6h", "to": "now"},
+		"tags":          []string{"reqres-controller"},
+		"templating": map[string]interface{}{
+			"list": []map[string]interface{}{{
+				"name":  "datasource",
+				"label": "Data source",
+				"type":  "datasource",
+				"query": "prometheus",
+			}},
+		},
+		"panels": grafanaPanels,
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(dashboard); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -17,23 +17,35 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
+	"net/http"
 	"os"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"github.com/spf13/viper"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	usersv1alpha1 "github.com/adrafiq/reqres-controller/api/v1alpha1"
+	usersv1beta1 "github.com/adrafiq/reqres-controller/api/v1beta1"
 	"github.com/adrafiq/reqres-controller/controllers"
+	"github.com/adrafiq/reqres-controller/pkg/backend"
 	envConfig "github.com/adrafiq/reqres-controller/pkg/config"
+	"github.com/adrafiq/reqres-controller/pkg/reqres"
+	"github.com/adrafiq/reqres-controller/pkg/tracing"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -46,6 +58,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(usersv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(usersv1beta1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -66,6 +79,11 @@ func main() {
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	shutdownTracing, err := tracing.Setup(context.Background(), config)
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
@@ -74,6 +92,10 @@ func main() {
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "775edc24.reqres.in",
+		// ConfigMaps and Secrets are only watched for their metadata. The few
+		// referenced by USERs and ReqresBackends are read from the API server
+		// once they changed, instead of caching all of them cluster-wide.
+		ClientDisableCacheFor: []client.Object{&corev1.ConfigMap{}, &corev1.Secret{}},
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -91,14 +113,57 @@ func main() {
 		os.Exit(1)
 	}
 
+	reqresClient := newReqresClient(config)
+	reqresClient.Breaker.Report("reqres", "")
+	backends := backend.NewRegistry("reqres", reqresClient)
+	if config.GetBool("REQRES_MEMORY_BACKEND") {
+		backends.Register("memory", backend.NewMemory())
+	}
+	reqresClients := controllers.NewReqresClients(func() *reqres.Client { return newReqresClient(config) })
 	if err = (&controllers.USERReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		Config: config,
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		Config:        config,
+		Backends:      backends,
+		ReqresClients: reqresClients,
+		Recorder: controllers.NewRateLimitedRecorder(
+			mgr.GetEventRecorderFor("user-controller"),
+			config.GetDuration("REQRES_EVENT_REPEAT_INTERVAL"),
+		),
+		Metadata: mgr.GetCache(),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "USER")
 		os.Exit(1)
 	}
+	if err = (&controllers.BackendProfileReconciler{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Backends:  backends,
+		NewClient: func() *reqres.Client { return newReqresClient(config) },
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "BackendProfile")
+		os.Exit(1)
+	}
+	if err = (&controllers.ReqresBackendReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Config:  config,
+		Clients: reqresClients,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ReqresBackend")
+		os.Exit(1)
+	}
+	metrics.Registry.MustRegister(controllers.NewUserCollector(mgr.GetClient()))
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&usersv1alpha1.USER{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "USER")
+			os.Exit(1)
+		}
+		if err = (&usersv1beta1.USER{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "USER")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -109,10 +174,52 @@ func main() {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	// The pod's readiness probe excludes this check, so that an open circuit
+	// does not take the conversion and admission webhooks with it.
+	if err := mgr.AddReadyzCheck("backend", reqresClient.Breaker.ReadyzCheck); err != nil {
+		setupLog.Error(err, "unable to set up backend ready check")
+		os.Exit(1)
+	}
 
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
+	if err := shutdownTracing(context.Background()); err != nil {
+		setupLog.Error(err, "unable to flush traces")
+	}
+}
+
+// newReqresClient builds a backend client from the configuration. Besides
+// the reqres backend, every BackendProfile and ReqresBackend gets one of its
+// own.
+func newReqresClient(config *viper.Viper) *reqres.Client {
+	logger := ctrl.Log.WithName("reqres")
+	client := reqres.NewClient(strings.TrimSuffix(config.GetString("REQRES_ROOT_URL"), "/"), &logger)
+	client.HTTPClient = &http.Client{
+		Transport: reqres.NewTransport(config.GetInt("REQRES_MAX_CONNS_PER_HOST")),
+	}
+	client.Limiter = rate.NewLimiter(
+		rate.Limit(config.GetFloat64("REQRES_RATE_LIMIT_QPS")),
+		config.GetInt("REQRES_RATE_LIMIT_BURST"),
+	)
+	client.Timeouts = reqres.Timeouts{
+		Create: config.GetDuration("REQRES_CREATE_TIMEOUT"),
+		Get:    config.GetDuration("REQRES_GET_TIMEOUT"),
+		Update: config.GetDuration("REQRES_UPDATE_TIMEOUT"),
+		Delete: config.GetDuration("REQRES_DELETE_TIMEOUT"),
+	}
+	client.Retry = &reqres.ExponentialBackoff{
+		MaxAttempts: config.GetInt("REQRES_RETRY_MAX_ATTEMPTS"),
+		BaseDelay:   config.GetDuration("REQRES_RETRY_BASE_DELAY"),
+		MaxDelay:    config.GetDuration("REQRES_RETRY_MAX_DELAY"),
+		Budget:      config.GetDuration("REQRES_RETRY_BUDGET"),
+	}
+	client.SupportsIdempotencyKey = config.GetBool("REQRES_SUPPORTS_IDEMPOTENCY_KEY")
+	client.Breaker = reqres.NewCircuitBreaker(
+		config.GetInt("REQRES_BREAKER_FAILURE_THRESHOLD"),
+		config.GetDuration("REQRES_BREAKER_COOLDOWN"),
+	)
+	return &client
 }
@@ -0,0 +1,110 @@
+// Package backend decouples the controller from the system users are managed
+// in. Backends are registered by name and selected per USER or namespace.
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/adrafiq/reqres-controller/pkg/reqres"
+)
+
+// UserBackend manages users in an external system. reqres.Client is the
+// reference implementation; others return errors wrapping the reqres
+// sentinel errors, so that callers classify failures alike.
+type UserBackend interface {
+	// CreateUser creates user. A repeated create with the same non-empty
+	// idempotencyKey should return the user created first.
+	CreateUser(ctx context.Context, user reqres.User, idempotencyKey string) (*reqres.User, error)
+	GetUser(ctx context.Context, id string) (*reqres.User, error)
+	// PatchUser sets the fields of diff, leaving all others alone.
+	PatchUser(ctx context.Context, id string, diff reqres.Diff) error
+	DeleteUser(ctx context.Context, id string) (bool, error)
+	// ListUsers returns a page of users, pages starting at 1.
+	ListUsers(ctx context.Context, page, perPage int) (*reqres.UserPage, error)
+}
+
+var (
+	_ UserBackend = &reqres.Client{}
+	_ UserBackend = &reqres.ProfileClient{}
+)
+
+// Suspender is implemented by backends that hold off calls while failing,
+// such as reqres.Client with a circuit breaker.
+type Suspender interface {
+	// RetryAfter returns how long calls are suspended, if at all.
+	RetryAfter() time.Duration
+}
+
+// ErrUnknownBackend is returned for a name nothing was registered under.
+var ErrUnknownBackend = errors.New("unknown backend")
+
+// Registry holds the backends USERs can select by name.
+type Registry struct {
+	mu          sync.RWMutex
+	backends    map[string]UserBackend
+	defaultName string
+}
+
+// NewRegistry returns a registry with defaultBackend registered as
+// defaultName, which is used when a USER selects no backend.
+func NewRegistry(defaultName string, defaultBackend UserBackend) *Registry {
+	r := &Registry{backends: map[string]UserBackend{}, defaultName: defaultName}
+	r.Register(defaultName, defaultBackend)
+	return r
+}
+
+// Register makes backend selectable as name, replacing any backend
+// registered under that name before.
+func (r *Registry) Register(name string, backend UserBackend) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.backends[name] = backend
+}
+
+// Unregister removes the backend registered as name. The default backend
+// cannot be removed.
+func (r *Registry) Unregister(name string) {
+	if name == r.defaultName {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.backends, name)
+}
+
+// Get returns the backend registered as name, or the default backend if name
+// is empty. The error wraps ErrUnknownBackend if there is none.
+func (r *Registry) Get(name string) (UserBackend, error) {
+	if name == "" {
+		name = r.defaultName
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	backend, ok := r.backends[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownBackend, name)
+	}
+	return backend, nil
+}
+
+// Default returns the name of the default backend.
+func (r *Registry) Default() string {
+	return r.defaultName
+}
+
+// Names returns the names of all registered backends, sorted.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.backends))
+	for name := range r.backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
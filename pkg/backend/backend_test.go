@@ -0,0 +1,74 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/adrafiq/reqres-controller/pkg/reqres"
+)
+
+func TestRegistry(t *testing.T) {
+	reqresClient := reqres.NewClient("https://reqres.in", nil)
+	memory := NewMemory()
+	registry := NewRegistry("reqres", &reqresClient)
+	registry.Register("memory", memory)
+
+	for name, want := range map[string]UserBackend{"": &reqresClient, "reqres": &reqresClient, "memory": memory} {
+		if got, err := registry.Get(name); err != nil || got != want {
+			t.Errorf("Get(%q) = %v, %v", name, got, err)
+		}
+	}
+	if _, err := registry.Get("identity"); !errors.Is(err, ErrUnknownBackend) {
+		t.Errorf("Get of an unregistered backend: %v", err)
+	}
+
+	registry.Unregister("memory")
+	registry.Unregister("reqres")
+	if names := registry.Names(); len(names) != 1 || names[0] != "reqres" {
+		t.Errorf("Names() after Unregister = %v", names)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	memory := NewMemory()
+	janet, err := memory.CreateUser(ctx, reqres.User{Email: "janet.weaver@reqres.in", FirstName: "Janet"}, "key-1")
+	if err != nil || janet.Id != "1" {
+		t.Fatalf("CreateUser() = %+v, %v", janet, err)
+	}
+	if again, _ := memory.CreateUser(ctx, reqres.User{Email: "janet.weaver@reqres.in"}, "key-1"); again.Id != janet.Id {
+		t.Errorf("repeated create made user %s", again.Id)
+	}
+	for _, email := range []string{"emma.wong@reqres.in", "eve.holt@reqres.in"} {
+		if _, err := memory.CreateUser(ctx, reqres.User{Email: email}, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	diff := reqres.Compare(reqres.User{Email: janet.Email, FirstName: "Janet", LastName: "Weaver"}, *janet)
+	if err := memory.PatchUser(ctx, janet.Id, diff); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := memory.GetUser(ctx, janet.Id); got.LastName != "Weaver" || got.FirstName != "Janet" {
+		t.Errorf("patched user is %+v", got)
+	}
+
+	page, err := memory.ListUsers(ctx, 2, 2)
+	if err != nil || page.TotalPages != 2 || len(page.Users) != 1 || page.Users[0].Email != "eve.holt@reqres.in" {
+		t.Errorf("ListUsers(2, 2) = %+v, %v", page, err)
+	}
+	if found, err := reqres.FindUserByEmail(ctx, memory, "Eve.Holt@reqres.in"); err != nil || found.Id != "3" {
+		t.Errorf("FindUserByEmail() = %+v, %v", found, err)
+	}
+
+	if _, err := memory.DeleteUser(ctx, janet.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := memory.GetUser(ctx, janet.Id); !errors.Is(err, reqres.ErrNotFound) {
+		t.Errorf("GetUser of a deleted user: %v", err)
+	}
+	if _, err := memory.DeleteUser(ctx, janet.Id); !errors.Is(err, reqres.ErrNotFound) {
+		t.Errorf("DeleteUser of a deleted user: %v", err)
+	}
+}
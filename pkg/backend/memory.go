@@ -0,0 +1,119 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/adrafiq/reqres-controller/pkg/reqres"
+)
+
+// Memory is a UserBackend keeping users in memory, for tests and demos. Ids
+// are assigned sequentially from 1.
+type Memory struct {
+	mu     sync.Mutex
+	users  map[string]reqres.User
+	order  []string
+	keys   map[string]string
+	nextID int
+}
+
+var _ UserBackend = &Memory{}
+
+// NewMemory returns an empty in-memory backend.
+func NewMemory() *Memory {
+	return &Memory{
+		users:  map[string]reqres.User{},
+		keys:   map[string]string{},
+		nextID: 1,
+	}
+}
+
+func (m *Memory) CreateUser(_ context.Context, user reqres.User, idempotencyKey string) (*reqres.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if id, ok := m.keys[idempotencyKey]; ok && idempotencyKey != "" {
+		created := m.users[id]
+		return &created, nil
+	}
+	user.Id = strconv.Itoa(m.nextID)
+	m.nextID++
+	m.users[user.Id] = user
+	m.order = append(m.order, user.Id)
+	if idempotencyKey != "" {
+		m.keys[idempotencyKey] = user.Id
+	}
+	return &user, nil
+}
+
+func (m *Memory) GetUser(_ context.Context, id string) (*reqres.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	user, err := m.lookup(reqres.OpGet, id)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (m *Memory) PatchUser(_ context.Context, id string, diff reqres.Diff) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	user, err := m.lookup(reqres.OpUpdate, id)
+	if err != nil {
+		return err
+	}
+	m.users[id] = diff.Apply(user)
+	return nil
+}
+
+func (m *Memory) DeleteUser(_ context.Context, id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.lookup(reqres.OpDelete, id); err != nil {
+		return false, err
+	}
+	delete(m.users, id)
+	for i, ordered := range m.order {
+		if ordered == id {
+			m.order = append(m.order[:i], m.order[i+1:]...)
+			break
+		}
+	}
+	return true, nil
+}
+
+// ListUsers pages through the users in the order they were created. A
+// perPage of 0 returns them all at once.
+func (m *Memory) ListUsers(_ context.Context, page, perPage int) (*reqres.UserPage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if page < 1 {
+		return nil, fmt.Errorf("memory %s: page %d: %w", reqres.OpList, page, reqres.ErrPermanent)
+	}
+	total := len(m.order)
+	if perPage <= 0 {
+		perPage = total
+	}
+	result := &reqres.UserPage{Page: page, PerPage: perPage, Total: total, Users: []reqres.User{}}
+	if perPage == 0 {
+		return result, nil
+	}
+	result.TotalPages = (total + perPage - 1) / perPage
+	for i := (page - 1) * perPage; i < total && i < page*perPage; i++ {
+		result.Users = append(result.Users, m.users[m.order[i]])
+	}
+	return result, nil
+}
+
+func (m *Memory) lookup(op, id string) (reqres.User, error) {
+	if id == "" {
+		return reqres.User{}, fmt.Errorf("memory %s: empty user id: %w", op, reqres.ErrPermanent)
+	}
+	user, ok := m.users[id]
+	if !ok {
+		return reqres.User{}, fmt.Errorf("memory %s: user %s: %w", op, id, reqres.ErrNotFound)
+	}
+	return user, nil
+}
@@ -1,13 +1,46 @@
 package config
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
 func New() *viper.Viper {
 	var envConfig = viper.New()
 	// if env file, then that else os.env
-	envConfig.Set("REQRES_ROOT_URL", "https://reqres.in")
+	envConfig.SetDefault("REQRES_ROOT_URL", "https://reqres.in")
+	// per-operation deadlines for backend calls, e.g. REQRES_GET_TIMEOUT=2s
+	envConfig.SetDefault("REQRES_CREATE_TIMEOUT", 10*time.Second)
+	envConfig.SetDefault("REQRES_GET_TIMEOUT", 5*time.Second)
+	envConfig.SetDefault("REQRES_UPDATE_TIMEOUT", 10*time.Second)
+	envConfig.SetDefault("REQRES_DELETE_TIMEOUT", 10*time.Second)
+	// retry policy for failed backend calls; budget caps the time across attempts
+	envConfig.SetDefault("REQRES_RETRY_MAX_ATTEMPTS", 4)
+	envConfig.SetDefault("REQRES_RETRY_BASE_DELAY", 200*time.Millisecond)
+	envConfig.SetDefault("REQRES_RETRY_MAX_DELAY", 10*time.Second)
+	envConfig.SetDefault("REQRES_RETRY_BUDGET", 30*time.Second)
+	// only set for backends recognising repeated creates by their Idempotency-Key
+	envConfig.SetDefault("REQRES_SUPPORTS_IDEMPOTENCY_KEY", false)
+	// consecutive failed calls that open the circuit, and how long it stays open
+	envConfig.SetDefault("REQRES_BREAKER_FAILURE_THRESHOLD", 5)
+	envConfig.SetDefault("REQRES_BREAKER_COOLDOWN", 30*time.Second)
+	// global request budget towards the backend, shared by all reconciles
+	envConfig.SetDefault("REQRES_RATE_LIMIT_QPS", 10)
+	envConfig.SetDefault("REQRES_RATE_LIMIT_BURST", 20)
+	envConfig.SetDefault("REQRES_MAX_CONNS_PER_HOST", 20)
+	// how often each ReqresBackend's endpoint is probed for its Ready condition
+	envConfig.SetDefault("REQRES_BACKEND_PROBE_INTERVAL", time.Minute)
+	// how often backend users are checked for drift, unless spec.syncInterval is set
+	envConfig.SetDefault("REQRES_SYNC_INTERVAL", 10*time.Minute)
+	// registers an in-memory backend as "memory", for demos and end-to-end tests
+	envConfig.SetDefault("REQRES_MEMORY_BACKEND", false)
+	// repeats of a warning event for the same user within this interval are dropped
+	envConfig.SetDefault("REQRES_EVENT_REPEAT_INTERVAL", 5*time.Minute)
+	// tracing, spans are only exported over OTLP/HTTP when an endpoint is set
+	envConfig.SetDefault("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	envConfig.SetDefault("OTEL_SERVICE_NAME", "reqres-controller")
+	envConfig.SetDefault("OTEL_TRACES_SAMPLER_ARG", 1.0)
 	envConfig.AutomaticEnv()
 	return envConfig
 }
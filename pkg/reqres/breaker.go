@@ -0,0 +1,184 @@
+package reqres
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a CircuitBreaker.
+type BreakerState int
+
+const (
+	// StateClosed lets every call through.
+	StateClosed BreakerState = iota
+	// StateOpen rejects every call until the cool-down has passed.
+	StateOpen
+	// StateHalfOpen lets a single probe through to test the backend.
+	StateHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// ErrCircuitOpen is matched by errors returned while the breaker rejects calls.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitOpenError is returned instead of calling the backend while the
+// circuit is open.
+type CircuitOpenError struct {
+	Op string
+	// RetryAfter is the time left until the breaker lets a probe through.
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("reqres %s: %s, retry in %s", e.Op, ErrCircuitOpen, e.RetryAfter)
+}
+
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitBreaker stops calls to the backend after FailureThreshold
+// consecutive failures. After CoolDown it lets one probe through, and closes
+// again if that probe succeeds. It is safe for concurrent use and meant to be
+// shared by every reconcile.
+type CircuitBreaker struct {
+	FailureThreshold int
+	CoolDown         time.Duration
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+	// backend and namespace label the state exported, once Report was called.
+	backend   string
+	namespace string
+}
+
+func NewCircuitBreaker(failureThreshold int, coolDown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		FailureThreshold: failureThreshold,
+		CoolDown:         coolDown,
+	}
+}
+
+// Report exports the state as the breaker of backend, the name USERs record
+// in status.backend, with the namespace of a ReqresBackend. Call
+// ForgetBreaker once the backend is gone.
+func (b *CircuitBreaker) Report(backend, namespace string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.backend, b.namespace = backend, namespace
+	b.setState(b.state)
+}
+
+// ForgetBreaker stops exporting the breaker state of a backend.
+func ForgetBreaker(backend, namespace string) {
+	breakerState.DeleteLabelValues(backend, namespace)
+}
+
+// State returns the current state, moving from open to half-open once the
+// cool-down has passed.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	return b.state
+}
+
+// RetryAfter returns how long the circuit stays open, or 0 if it is not open.
+func (b *CircuitBreaker) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	if b.state != StateOpen {
+		return 0
+	}
+	return b.CoolDown - time.Since(b.openedAt)
+}
+
+// allow reserves a call. In half-open state only one probe is in flight.
+func (b *CircuitBreaker) allow(op string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	switch b.state {
+	case StateOpen:
+		return &CircuitOpenError{Op: op, RetryAfter: b.CoolDown - time.Since(b.openedAt)}
+	case StateHalfOpen:
+		if b.probing {
+			return &CircuitOpenError{Op: op, RetryAfter: b.CoolDown}
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// record reports the outcome of a call reserved with allow.
+func (b *CircuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		b.setState(StateClosed)
+		return
+	}
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.FailureThreshold {
+		b.openedAt = time.Now()
+		b.setState(StateOpen)
+	}
+}
+
+// release gives back a reservation without judging the backend, e.g. when
+// the caller's context was cancelled.
+func (b *CircuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *CircuitBreaker) advance() {
+	if b.state == StateOpen && time.Since(b.openedAt) >= b.CoolDown {
+		b.setState(StateHalfOpen)
+	}
+}
+
+func (b *CircuitBreaker) setState(state BreakerState) {
+	b.state = state
+	if b.backend != "" {
+		breakerState.WithLabelValues(b.backend, b.namespace).Set(float64(state))
+	}
+}
+
+// ReadyzCheck implements healthz.Checker and fails while the circuit is open.
+func (b *CircuitBreaker) ReadyzCheck(_ *http.Request) error {
+	if retryAfter := b.RetryAfter(); retryAfter > 0 {
+		return fmt.Errorf("reqres backend unavailable: %w, retry in %s", ErrCircuitOpen, retryAfter)
+	}
+	return nil
+}
+
+// breakerFailure reports whether the outcome of a call counts against the
+// backend's health. Client errors such as 404 do not.
+func breakerFailure(res *response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, ErrCircuitOpen)
+	}
+	return retryableStatus(res.StatusCode)
+}
@@ -0,0 +1,160 @@
+package reqres
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestBreakerReport(t *testing.T) {
+	breaker := NewCircuitBreaker(1, time.Minute)
+	breaker.record(true)
+	if n := testutil.CollectAndCount(breakerState); n != 0 {
+		t.Fatalf("unreported breaker exported %d series", n)
+	}
+
+	breaker.Report("ReqresBackend/staging", "team-a")
+	other := NewCircuitBreaker(1, time.Minute)
+	other.Report("ReqresBackend/staging", "team-b")
+	if got := testutil.ToFloat64(breakerState.WithLabelValues("ReqresBackend/staging", "team-a")); got != float64(StateOpen) {
+		t.Errorf("state of team-a = %v, want open", got)
+	}
+	if got := testutil.ToFloat64(breakerState.WithLabelValues("ReqresBackend/staging", "team-b")); got != float64(StateClosed) {
+		t.Errorf("state of team-b = %v, want closed", got)
+	}
+
+	ForgetBreaker("ReqresBackend/staging", "team-a")
+	ForgetBreaker("ReqresBackend/staging", "team-b")
+	if n := testutil.CollectAndCount(breakerState); n != 0 {
+		t.Errorf("forgotten breakers still export %d series", n)
+	}
+}
+
+func TestBreakerTransitions(t *testing.T) {
+	// Each step is one call through allow and record, or cools the breaker
+	// down. allowed is whether allow let the call through.
+	type step struct {
+		coolDown bool
+		failed   bool
+		allowed  bool
+		state    BreakerState
+	}
+	for _, tc := range []struct {
+		name  string
+		steps []step
+	}{{
+		name: "failures below the threshold",
+		steps: []step{
+			{failed: true, allowed: true, state: StateClosed},
+			{failed: true, allowed: true, state: StateClosed},
+			{allowed: true, state: StateClosed},
+			{failed: true, allowed: true, state: StateClosed},
+		},
+	}, {
+		name: "closed, open, half-open and closed",
+		steps: []step{
+			{failed: true, allowed: true, state: StateClosed},
+			{failed: true, allowed: true, state: StateClosed},
+			{failed: true, allowed: true, state: StateOpen},
+			{state: StateOpen},
+			{coolDown: true, state: StateHalfOpen},
+			{allowed: true, state: StateClosed},
+			{failed: true, allowed: true, state: StateClosed},
+		},
+	}, {
+		name: "failed probe",
+		steps: []step{
+			{failed: true, allowed: true, state: StateClosed},
+			{failed: true, allowed: true, state: StateClosed},
+			{failed: true, allowed: true, state: StateOpen},
+			{coolDown: true, state: StateHalfOpen},
+			{failed: true, allowed: true, state: StateOpen},
+			{state: StateOpen},
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			breaker := NewCircuitBreaker(3, time.Minute)
+			for i, s := range tc.steps {
+				if s.coolDown {
+					breaker.mu.Lock()
+					breaker.openedAt = breaker.openedAt.Add(-breaker.CoolDown)
+					breaker.mu.Unlock()
+				} else {
+					err := breaker.allow(OpGet)
+					if allowed := err == nil; allowed != s.allowed {
+						t.Fatalf("step %d: allow() = %v", i, err)
+					}
+					if err != nil && !errors.Is(err, ErrCircuitOpen) {
+						t.Fatalf("step %d: allow() = %v, want ErrCircuitOpen", i, err)
+					}
+					if err == nil {
+						breaker.record(s.failed)
+					}
+				}
+				if state := breaker.State(); state != s.state {
+					t.Fatalf("step %d: state = %s, want %s", i, state, s.state)
+				}
+			}
+		})
+	}
+
+	// A half-open breaker lets a single probe through at a time.
+	breaker := NewCircuitBreaker(1, time.Minute)
+	breaker.record(true)
+	breaker.openedAt = breaker.openedAt.Add(-breaker.CoolDown)
+	if err := breaker.allow(OpGet); err != nil {
+		t.Fatalf("probe refused: %v", err)
+	}
+	if err := breaker.allow(OpGet); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("second call while probing = %v, want ErrCircuitOpen", err)
+	}
+	breaker.release()
+	if err := breaker.allow(OpGet); err != nil {
+		t.Errorf("probe refused after release: %v", err)
+	}
+}
+
+func TestBreakerFailure(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		status int
+		err    error
+		failed bool
+	}{
+		{name: "ok", status: 200},
+		{name: "not found", status: 404},
+		{name: "bad request", status: 400},
+		{name: "throttled", status: 429, failed: true},
+		{name: "server error", status: 503, failed: true},
+		{name: "transport error", err: &TransportError{Op: OpGet, Err: errors.New("connection refused")}, failed: true},
+		{name: "circuit open", err: &CircuitOpenError{Op: OpGet}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var res *response
+			if tc.err == nil {
+				res = &response{Response: &http.Response{StatusCode: tc.status}}
+			}
+			if failed := breakerFailure(res, tc.err); failed != tc.failed {
+				t.Errorf("breakerFailure() = %v, want %v", failed, tc.failed)
+			}
+		})
+	}
+}
+
+func TestBreakerReadyzCheck(t *testing.T) {
+	breaker := NewCircuitBreaker(1, time.Minute)
+	if err := breaker.ReadyzCheck(nil); err != nil {
+		t.Errorf("ReadyzCheck() while closed = %v", err)
+	}
+	breaker.record(true)
+	if err := breaker.ReadyzCheck(nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("ReadyzCheck() while open = %v, want ErrCircuitOpen", err)
+	}
+	breaker.openedAt = breaker.openedAt.Add(-breaker.CoolDown)
+	if err := breaker.ReadyzCheck(nil); err != nil {
+		t.Errorf("ReadyzCheck() while half-open = %v", err)
+	}
+}
@@ -1,22 +1,294 @@
 package reqres
 
 import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
 )
 
+// Timeouts bounds each attempt of a backend operation. A zero value leaves
+// the attempt bound only by the context passed in by the caller.
+type Timeouts struct {
+	Create time.Duration
+	Get    time.Duration
+	Update time.Duration
+	Delete time.Duration
+}
+
+// DefaultTimeouts are used by NewClient until overridden.
+var DefaultTimeouts = Timeouts{
+	Create: 10 * time.Second,
+	Get:    5 * time.Second,
+	Update: 10 * time.Second,
+	Delete: 10 * time.Second,
+}
+
+// Client calls a reqres compatible backend. Its connections, Limiter and
+// Breaker only account for the calls made through it, so one Client should
+// serve every reconcile talking to the same backend.
 type Client struct {
 	HTTPClient *http.Client
 	HostUrl    string
-	logger     *logr.Logger
+	Timeouts   Timeouts
+	Retry      RetryPolicy
+	// Header is sent with every request, e.g. to carry an API key.
+	Header http.Header
+	// SupportsIdempotencyKey is set for backends that recognise a repeated
+	// POST by its Idempotency-Key, which makes it safe to retry one the
+	// backend may have acted on. Otherwise POSTs are only retried when they
+	// were refused or never sent, as repeating a create could create the
+	// user twice.
+	SupportsIdempotencyKey bool
+	// Limiter is optional and throttles every attempt, including retries.
+	Limiter *rate.Limiter
+	// Breaker is optional and should be shared by every client talking to
+	// the same backend.
+	Breaker *CircuitBreaker
+	logger  *logr.Logger
 }
 
 func NewClient(host string, logger *logr.Logger) Client {
 	client := Client{
 		HostUrl:    host,
 		HTTPClient: &http.Client{},
+		Timeouts:   DefaultTimeouts,
+		Retry:      DefaultRetryPolicy,
 		logger:     logger,
 	}
 	return client
 }
+
+// RetryAfter returns how long calls are suspended by the circuit breaker, if
+// at all.
+func (c *Client) RetryAfter() time.Duration {
+	if c.Breaker == nil {
+		return 0
+	}
+	return c.Breaker.RetryAfter()
+}
+
+// NewTransport returns a transport tuned for many concurrent reconciles
+// talking to a single backend host. maxConnsPerHost also bounds the idle pool.
+func NewTransport(maxConnsPerHost int) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = maxConnsPerHost
+	transport.MaxIdleConnsPerHost = maxConnsPerHost
+	transport.MaxConnsPerHost = maxConnsPerHost
+	transport.IdleConnTimeout = 90 * time.Second
+	return transport
+}
+
+// withTimeout derives the context for a single attempt, so a hung backend
+// cannot outlive the per-operation budget or the caller's context.
+func (c *Client) withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func (c *Client) timeout(op string) time.Duration {
+	switch op {
+	case OpCreate:
+		return c.Timeouts.Create
+	case OpGet, OpList:
+		return c.Timeouts.Get
+	case OpUpdate:
+		return c.Timeouts.Update
+	case OpDelete:
+		return c.Timeouts.Delete
+	}
+	return 0
+}
+
+// log prefers the logger carried by ctx, which has the reconcile's key/values.
+func (c *Client) log(ctx context.Context) logr.Logger {
+	if logger, err := logr.FromContext(ctx); err == nil {
+		return logger
+	}
+	if c.logger != nil {
+		return *c.logger
+	}
+	return logr.Discard()
+}
+
+// response is a backend reply whose body has already been read and closed.
+type response struct {
+	*http.Response
+	body     []byte
+	attempts int
+}
+
+// tracer starts the client spans of backend calls.
+var tracer = otel.Tracer("github.com/adrafiq/reqres-controller/pkg/reqres")
+
+// do sends a request to path, retrying according to c.Retry and guarded by
+// c.Breaker. A non-nil error is a *TransportError or a *CircuitOpenError;
+// HTTP statuses are left to the caller. Each call is a client span, whose
+// context is propagated to the backend.
+func (c *Client) do(ctx context.Context, op, method, path string, payload []byte, header http.Header) (*response, error) {
+	ctx, span := tracer.Start(ctx, "reqres "+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPMethodKey.String(method),
+			semconv.HTTPURLKey.String(c.HostUrl+path),
+		),
+	)
+	defer span.End()
+	res, err := c.doWithBreaker(ctx, op, method, path, payload, header)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(
+		semconv.HTTPStatusCodeKey.Int(res.StatusCode),
+		attribute.Int("reqres.attempts", res.attempts),
+	)
+	if res.StatusCode >= 400 {
+		span.SetStatus(codes.Error, http.StatusText(res.StatusCode))
+	}
+	return res, nil
+}
+
+func (c *Client) doWithBreaker(ctx context.Context, op, method, path string, payload []byte, header http.Header) (*response, error) {
+	if c.Breaker == nil {
+		return c.doWithRetry(ctx, op, method, path, payload, header)
+	}
+	if err := c.Breaker.allow(op); err != nil {
+		return nil, err
+	}
+	res, err := c.doWithRetry(ctx, op, method, path, payload, header)
+	if ctx.Err() != nil {
+		c.Breaker.release()
+	} else {
+		c.Breaker.record(breakerFailure(res, err))
+	}
+	return res, err
+}
+
+func (c *Client) doWithRetry(ctx context.Context, op, method, path string, payload []byte, header http.Header) (*response, error) {
+	logger := c.log(ctx).WithValues("operation", op)
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		if c.Limiter != nil {
+			if err := c.Limiter.Wait(ctx); err != nil {
+				return nil, &TransportError{Op: op, Err: err, Attempts: attempt - 1}
+			}
+		}
+		req, res, err := c.attempt(ctx, op, method, path, payload, header)
+		if req == nil {
+			return nil, &TransportError{Op: op, Err: err, Attempts: attempt}
+		}
+		if err == nil && !retryableStatus(res.StatusCode) {
+			res.attempts = attempt
+			return res, nil
+		}
+		if ctx.Err() != nil {
+			return nil, &TransportError{Op: op, Err: ctx.Err(), Attempts: attempt}
+		}
+		retry := c.Retry
+		if retry == nil {
+			retry = NoRetry{}
+		}
+		var httpRes *http.Response
+		if res != nil {
+			httpRes = res.Response
+		}
+		var delay time.Duration
+		ok := c.repeatable(req, httpRes, err)
+		if ok {
+			delay, ok = retry.Next(attempt, time.Since(start), req, httpRes, err)
+		}
+		if !ok {
+			if err != nil {
+				return nil, &TransportError{Op: op, Err: err, Attempts: attempt}
+			}
+			res.attempts = attempt
+			return res, nil
+		}
+		reason := "transport"
+		if res != nil {
+			reason = strconv.Itoa(res.StatusCode)
+		}
+		retriesTotal.WithLabelValues(op, reason).Inc()
+		logger.Info("retrying backend request", "attempt", attempt, "reason", reason, "delay", delay.String(), "error", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, &TransportError{Op: op, Err: ctx.Err(), Attempts: attempt}
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt makes a single request. The request is nil if it could not be built.
+func (c *Client) attempt(ctx context.Context, op, method, path string, payload []byte, header http.Header) (*http.Request, *response, error) {
+	ctx, cancel := c.withTimeout(ctx, c.timeout(op))
+	defer cancel()
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.HostUrl+path, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	for key, values := range c.Header {
+		req.Header[key] = values
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	start := time.Now()
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		requestDuration.WithLabelValues(op, "error").Observe(time.Since(start).Seconds())
+		return req, nil, err
+	}
+	defer res.Body.Close()
+	resBody, err := ioutil.ReadAll(res.Body)
+	requestDuration.WithLabelValues(op, strconv.Itoa(res.StatusCode)).Observe(time.Since(start).Seconds())
+	if err != nil {
+		return req, nil, err
+	}
+	return req, &response{Response: res, body: resBody}, nil
+}
+
+// repeatable reports whether sending req again is safe. A POST may have
+// been acted on already, unless the backend refused it, it never left or
+// the backend recognises it by its idempotency key.
+func (c *Client) repeatable(req *http.Request, res *http.Response, err error) bool {
+	if req.Method != http.MethodPost {
+		return true
+	}
+	if c.SupportsIdempotencyKey && req.Header.Get("Idempotency-Key") != "" {
+		return true
+	}
+	if err != nil {
+		return isDialError(err)
+	}
+	return res.StatusCode == http.StatusTooManyRequests
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusRequestTimeout || code >= 500
+}
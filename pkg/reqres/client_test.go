@@ -0,0 +1,68 @@
+package reqres
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newBlockingServer returns a server that answers no request until the
+// client gives up on it, and counts the requests it receives on started.
+func newBlockingServer(t *testing.T, started chan<- struct{}) *httptest.Server {
+	t.Helper()
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+	return server
+}
+
+func TestClientTimeouts(t *testing.T) {
+	started := make(chan struct{}, 10)
+	client := NewClient(newBlockingServer(t, started).URL, nil)
+	client.Timeouts.Get = 20 * time.Millisecond
+	client.Retry = &ExponentialBackoff{MaxAttempts: 2, BaseDelay: time.Millisecond}
+
+	_, err := client.GetUser(context.Background(), "2")
+	var transportErr *TransportError
+	if !errors.As(err, &transportErr) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetUser() error = %v, want a TransportError wrapping context.DeadlineExceeded", err)
+	}
+	// The timeout bounds each attempt, not the whole call
+	if transportErr.Attempts != 2 || len(started) != 2 {
+		t.Errorf("made %d attempts, %d requests, want 2", transportErr.Attempts, len(started))
+	}
+}
+
+func TestClientCancellation(t *testing.T) {
+	started := make(chan struct{}, 10)
+	client := NewClient(newBlockingServer(t, started).URL, nil)
+	client.Retry = &ExponentialBackoff{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	start := time.Now()
+	_, err := client.GetUser(ctx, "2")
+	if elapsed := time.Since(start); elapsed >= client.Timeouts.Get {
+		t.Errorf("GetUser() returned after %v, the Get timeout, not on cancellation", elapsed)
+	}
+	var transportErr *TransportError
+	if !errors.As(err, &transportErr) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetUser() error = %v, want a TransportError wrapping context.Canceled", err)
+	}
+	if transportErr.Attempts != 1 || len(started) != 0 {
+		t.Errorf("made %d attempts, %d more requests, want a single attempt", transportErr.Attempts, len(started))
+	}
+}
@@ -0,0 +1,178 @@
+package reqres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	janet := User{
+		Id:        "2",
+		Email:     "janet.weaver@reqres.in",
+		FirstName: "Janet",
+		LastName:  "Weaver",
+		Avatar:    "https://reqres.in/img/faces/2-image.jpg",
+	}
+	for _, tc := range []struct {
+		name     string
+		desired  User
+		observed User
+		want     []string
+	}{{
+		name:     "equal",
+		desired:  janet,
+		observed: janet,
+	}, {
+		name:     "id is ignored",
+		desired:  User{Email: janet.Email, FirstName: "Janet", LastName: "Weaver"},
+		observed: janet,
+	}, {
+		name:     "email ignores case and space",
+		desired:  User{Email: " Janet.Weaver@ReqRes.in", FirstName: "Janet"},
+		observed: janet,
+	}, {
+		name:     "names are trimmed",
+		desired:  User{Email: janet.Email, FirstName: "Janet ", LastName: "\tWeaver"},
+		observed: janet,
+	}, {
+		name:     "names are case sensitive",
+		desired:  User{Email: janet.Email, FirstName: "janet", LastName: "WEAVER"},
+		observed: janet,
+		want:     []string{FieldFirstName, FieldLastName},
+	}, {
+		name:     "unset optional fields are not compared",
+		desired:  User{Email: janet.Email, FirstName: "Janet"},
+		observed: janet,
+	}, {
+		name:     "set optional fields are compared",
+		desired:  User{Email: janet.Email, FirstName: "Janet", LastName: "Holt", Avatar: "https://example.com/janet.png"},
+		observed: janet,
+		want:     []string{FieldLastName, FieldAvatar},
+	}, {
+		name:     "required fields are compared when unset",
+		desired:  User{LastName: "Weaver"},
+		observed: janet,
+		want:     []string{FieldEmail, FieldFirstName},
+	}, {
+		name:     "everything differs",
+		desired:  User{Email: "emma.wong@reqres.in", FirstName: "Emma", LastName: "Wong", Avatar: "https://example.com/emma.png"},
+		observed: janet,
+		want:     []string{FieldEmail, FieldFirstName, FieldLastName, FieldAvatar},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			diff := Compare(tc.desired, tc.observed)
+			if got := diff.Fields(); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("Compare() fields = %v, want %v", got, tc.want)
+			}
+			for _, field := range diff {
+				if field.Desired == field.Observed {
+					t.Errorf("%s reported with equal values %q", field.Field, field.Desired)
+				}
+			}
+			if patched := diff.Apply(tc.observed); len(Compare(tc.desired, patched)) != 0 {
+				t.Errorf("applied diff still differs: %v", Compare(tc.desired, patched).Fields())
+			}
+		})
+	}
+}
+
+func TestPatchUser(t *testing.T) {
+	observed := User{Id: "2", Email: "janet.weaver@reqres.in", FirstName: "Janet", LastName: "Weaver"}
+	for _, tc := range []struct {
+		name     string
+		id       string
+		desired  User
+		wantBody map[string]string
+		wantErr  error
+	}{{
+		name:     "only changed fields",
+		id:       "2",
+		desired:  User{Email: "janet.weaver@reqres.in", FirstName: "Janet", LastName: "Holt"},
+		wantBody: map[string]string{"last_name": "Holt"},
+	}, {
+		name:     "several fields",
+		id:       "2",
+		desired:  User{Email: "janet.holt@reqres.in", FirstName: "Jan", Avatar: "https://example.com/jan.png"},
+		wantBody: map[string]string{"email": "janet.holt@reqres.in", "first_name": "Jan", "avatar": "https://example.com/jan.png"},
+	}, {
+		name:     "values sent as compared",
+		id:       "2",
+		desired:  User{Email: " janet.weaver@reqres.in", FirstName: "Jan ", LastName: "\tHolt\n", Avatar: " https://example.com/jan.png "},
+		wantBody: map[string]string{"first_name": "Jan", "last_name": "Holt", "avatar": "https://example.com/jan.png"},
+	}, {
+		name:    "space only, no request",
+		id:      "2",
+		desired: User{Email: "janet.weaver@reqres.in ", FirstName: " Janet", LastName: "Weaver\t", Avatar: "  "},
+	}, {
+		name:    "no change, no request",
+		id:      "2",
+		desired: observed,
+	}, {
+		name:    "empty id",
+		desired: User{Email: "janet.weaver@reqres.in", FirstName: "Jan"},
+		wantErr: ErrPermanent,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotPath string
+			var gotBody map[string]string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPatch {
+					t.Errorf("method = %s, want PATCH", r.Method)
+				}
+				gotPath = r.URL.Path
+				_ = json.NewDecoder(r.Body).Decode(&gotBody)
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+			client := NewClient(server.URL, nil)
+
+			err := client.PatchUser(context.Background(), tc.id, Compare(tc.desired, observed))
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("PatchUser() error = %v, want %v", err, tc.wantErr)
+			}
+			if tc.wantBody == nil {
+				if gotPath != "" {
+					t.Errorf("unexpected request to %s", gotPath)
+				}
+				return
+			}
+			if gotPath != "/api/users/"+tc.id {
+				t.Errorf("path = %s, want /api/users/%s", gotPath, tc.id)
+			}
+			if !reflect.DeepEqual(gotBody, tc.wantBody) {
+				t.Errorf("body = %v, want %v", gotBody, tc.wantBody)
+			}
+		})
+	}
+}
+
+func TestPatchUserStatus(t *testing.T) {
+	diff := Compare(User{Email: "janet.weaver@reqres.in", FirstName: "Jan"}, User{Id: "2", Email: "janet.weaver@reqres.in", FirstName: "Janet"})
+	for _, tc := range []struct {
+		status  int
+		body    string
+		wantErr bool
+	}{
+		{status: http.StatusOK, body: `{"first_name":"Jan","updatedAt":"2022-11-20T10:00:00.000Z"}`},
+		{status: http.StatusNoContent},
+		{status: http.StatusBadRequest, body: `{"error":"invalid"}`, wantErr: true},
+	} {
+		t.Run(http.StatusText(tc.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+			client := NewClient(server.URL, nil)
+
+			if err := client.PatchUser(context.Background(), "2", diff); (err != nil) != tc.wantErr {
+				t.Errorf("PatchUser() error = %v, want error %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package reqres
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Operation names used in errors, logs and metrics.
+const (
+	OpCreate = "create"
+	OpGet    = "get"
+	OpUpdate = "update"
+	OpDelete = "delete"
+	OpList   = "list"
+)
+
+// Sentinel errors classifying backend failures. Errors returned by Client
+// wrap exactly one of them, so callers branch with errors.Is.
+var (
+	ErrNotFound     = errors.New("user not found")
+	ErrConflict     = errors.New("conflict")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrTransient    = errors.New("transient backend error")
+	ErrPermanent    = errors.New("permanent backend error")
+	ErrDecode       = errors.New("unable to decode response")
+)
+
+// maxErrorBody caps how much of an error response body is kept on APIError.
+const maxErrorBody = 4096
+
+// APIError is returned when the backend answers with an unexpected status.
+type APIError struct {
+	Op         string
+	StatusCode int
+	Body       string
+	RequestID  string
+	// RetryAfter is the delay requested by the backend, if any.
+	RetryAfter time.Duration
+	// Attempts is the number of requests made before giving up.
+	Attempts int
+	kind     error
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("reqres %s: http status %d: %s", e.Op, e.StatusCode, e.kind)
+	if e.Attempts > 1 {
+		msg += fmt.Sprintf(" after %d attempts", e.Attempts)
+	}
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(" (request id %s)", e.RequestID)
+	}
+	return msg
+}
+
+func (e *APIError) Unwrap() error {
+	return e.kind
+}
+
+// TransportError is returned when no response was received at all, e.g. on
+// connection failures or when the call's context expires.
+type TransportError struct {
+	Op       string
+	Err      error
+	Attempts int
+}
+
+func (e *TransportError) Error() string {
+	if e.Attempts > 1 {
+		return fmt.Sprintf("reqres %s: error making http request after %d attempts: %v", e.Op, e.Attempts, e.Err)
+	}
+	return fmt.Sprintf("reqres %s: error making http request: %v", e.Op, e.Err)
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+func (e *TransportError) Is(target error) bool {
+	return target == ErrTransient
+}
+
+// DecodeError is returned when a success response cannot be parsed.
+type DecodeError struct {
+	Op         string
+	StatusCode int
+	Body       string
+	RequestID  string
+	Err        error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("reqres %s: %s: %v", e.Op, ErrDecode, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrDecode
+}
+
+// StatusCode returns the HTTP status carried by err, or 0 if there is none.
+func StatusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) {
+		return decodeErr.StatusCode
+	}
+	return 0
+}
+
+func newAPIError(op string, res *response) error {
+	body := res.body
+	if len(body) > maxErrorBody {
+		body = body[:maxErrorBody]
+	}
+	after, _ := retryAfter(res.Response)
+	return &APIError{
+		Op:         op,
+		StatusCode: res.StatusCode,
+		Body:       string(body),
+		RequestID:  requestID(res.Response),
+		RetryAfter: after,
+		Attempts:   res.attempts,
+		kind:       classifyStatus(res.StatusCode),
+	}
+}
+
+func newDecodeError(op string, res *response, err error) error {
+	body := res.body
+	if len(body) > maxErrorBody {
+		body = body[:maxErrorBody]
+	}
+	return &DecodeError{
+		Op:         op,
+		StatusCode: res.StatusCode,
+		Body:       string(body),
+		RequestID:  requestID(res.Response),
+		Err:        err,
+	}
+}
+
+func classifyStatus(code int) error {
+	switch {
+	case code == http.StatusNotFound || code == http.StatusGone:
+		return ErrNotFound
+	case code == http.StatusConflict:
+		return ErrConflict
+	case code == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return ErrUnauthorized
+	case code == http.StatusRequestTimeout || code >= 500:
+		return ErrTransient
+	default:
+		return ErrPermanent
+	}
+}
+
+func requestID(res *http.Response) string {
+	for _, header := range []string{"X-Request-Id", "Cf-Ray"} {
+		if id := res.Header.Get(header); id != "" {
+			return id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+package reqres
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestErrorClassification(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		status int
+		body   string
+		kind   error
+		// code is the status StatusCode reports for the error.
+		code int
+	}{
+		{name: "not found", status: http.StatusNotFound, kind: ErrNotFound, code: 404},
+		{name: "gone", status: http.StatusGone, kind: ErrNotFound, code: 410},
+		{name: "conflict", status: http.StatusConflict, kind: ErrConflict, code: 409},
+		{name: "throttled", status: http.StatusTooManyRequests, kind: ErrRateLimited, code: 429},
+		{name: "unauthorized", status: http.StatusUnauthorized, kind: ErrUnauthorized, code: 401},
+		{name: "forbidden", status: http.StatusForbidden, kind: ErrUnauthorized, code: 403},
+		{name: "request timeout", status: http.StatusRequestTimeout, kind: ErrTransient, code: 408},
+		{name: "server error", status: http.StatusInternalServerError, kind: ErrTransient, code: 500},
+		{name: "bad gateway", status: http.StatusBadGateway, kind: ErrTransient, code: 502},
+		{name: "bad request", status: http.StatusBadRequest, kind: ErrPermanent, code: 400},
+		{name: "unprocessable", status: http.StatusUnprocessableEntity, kind: ErrPermanent, code: 422},
+		{name: "unexpected success", status: http.StatusNoContent, kind: ErrPermanent, code: 204},
+		{name: "undecodable", status: http.StatusOK, body: "<html>", kind: ErrDecode, code: 200},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Request-Id", "req-1")
+				w.Header().Set("Retry-After", "3")
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+			client := NewClient(server.URL, nil)
+			client.Retry = NoRetry{}
+
+			_, err := client.GetUser(context.Background(), "7")
+			for _, kind := range []error{ErrNotFound, ErrConflict, ErrRateLimited, ErrUnauthorized, ErrTransient, ErrPermanent, ErrDecode} {
+				if is := errors.Is(err, kind); is != (kind == tc.kind) {
+					t.Errorf("errors.Is(%v, %v) = %v", err, kind, is)
+				}
+			}
+			if code := StatusCode(err); code != tc.code {
+				t.Errorf("StatusCode() = %d, want %d", code, tc.code)
+			}
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && (apiErr.RequestID != "req-1" || apiErr.RetryAfter != 3*time.Second) {
+				t.Errorf("APIError = %+v, want request id and Retry-After", apiErr)
+			}
+		})
+	}
+}
+
+func TestErrorBodyIsCapped(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(strings.Repeat("x", 2*maxErrorBody)))
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, nil)
+
+	_, err := client.GetUser(context.Background(), "7")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || len(apiErr.Body) != maxErrorBody {
+		t.Errorf("GetUser() = %v, want a body of %d bytes", err, maxErrorBody)
+	}
+}
+
+func TestTransportErrorClassification(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	client := NewClient("http://"+addr, nil)
+	client.Retry = NoRetry{}
+
+	_, err = client.GetUser(context.Background(), "7")
+	var transportErr *TransportError
+	if !errors.As(err, &transportErr) || !errors.Is(err, ErrTransient) || errors.Is(err, ErrPermanent) {
+		t.Errorf("GetUser() against a closed port = %v, want a transient TransportError", err)
+	}
+	if code := StatusCode(err); code != 0 {
+		t.Errorf("StatusCode() = %d, want 0", code)
+	}
+
+	if _, err := client.GetUser(context.Background(), ""); !errors.Is(err, ErrPermanent) {
+		t.Errorf("GetUser(\"\") = %v, want ErrPermanent", err)
+	}
+}
@@ -0,0 +1,165 @@
+package reqres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type UserListResponse struct {
+	Page       int        `json:"page"`
+	PerPage    int        `json:"per_page"`
+	Total      int        `json:"total"`
+	TotalPages int        `json:"total_pages"`
+	Data       []UserData `json:"data"`
+}
+
+// UserPage is one page of users as returned by ListUsers.
+type UserPage struct {
+	Users      []User
+	Page       int
+	PerPage    int
+	Total      int
+	TotalPages int
+}
+
+// ListUsers fetches a single page of users. Pages start at 1; a perPage of 0
+// leaves the page size to the backend.
+func (c *Client) ListUsers(ctx context.Context, page, perPage int) (*UserPage, error) {
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(page))
+	if perPage > 0 {
+		query.Set("per_page", strconv.Itoa(perPage))
+	}
+	api := strings.TrimSuffix(usersApi, "/") + "?" + query.Encode()
+	res, err := c.do(ctx, OpList, http.MethodGet, api, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != httpGetSuccess {
+		return nil, newAPIError(OpList, res)
+	}
+	var listResponse UserListResponse
+	if err := json.Unmarshal(res.body, &listResponse); err != nil {
+		return nil, newDecodeError(OpList, res, err)
+	}
+	users := make([]User, 0, len(listResponse.Data))
+	for _, data := range listResponse.Data {
+		users = append(users, data.user())
+	}
+	return &UserPage{
+		Users:      users,
+		Page:       listResponse.Page,
+		PerPage:    listResponse.PerPage,
+		Total:      listResponse.Total,
+		TotalPages: listResponse.TotalPages,
+	}, nil
+}
+
+// UserLister lists users a page at a time, like Client.ListUsers.
+type UserLister interface {
+	ListUsers(ctx context.Context, page, perPage int) (*UserPage, error)
+}
+
+// FindUserByEmail walks all users for the one with the given email, compared
+// case-insensitively. The error wraps ErrNotFound if there is none.
+func (c *Client) FindUserByEmail(ctx context.Context, email string) (*User, error) {
+	return FindUserByEmail(ctx, c, email)
+}
+
+// FindUserByEmail is Client.FindUserByEmail for any UserLister.
+func FindUserByEmail(ctx context.Context, lister UserLister, email string) (*User, error) {
+	it := NewUserIterator(lister, findPerPage)
+	for it.Next(ctx) {
+		if user := it.User(); strings.EqualFold(user.Email, email) {
+			return &user, nil
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("reqres %s: no user with email %s: %w", OpList, email, ErrNotFound)
+}
+
+// findPerPage is the page size used when searching all users.
+const findPerPage = 100
+
+// UserIterator walks every user in the backend, fetching pages lazily.
+//
+//	it := client.Users(50)
+//	for it.Next(ctx) {
+//		user := it.User()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type UserIterator struct {
+	lister  UserLister
+	perPage int
+	// pageNumber is the page last requested, whatever the backend echoed.
+	pageNumber int
+	page       *UserPage
+	index      int
+	user       User
+	err        error
+	done       bool
+}
+
+// Users returns an iterator over all users, perPage at a time.
+func (c *Client) Users(perPage int) *UserIterator {
+	return NewUserIterator(c, perPage)
+}
+
+// NewUserIterator returns an iterator over all users of lister, perPage at a
+// time.
+func NewUserIterator(lister UserLister, perPage int) *UserIterator {
+	return &UserIterator{lister: lister, perPage: perPage}
+}
+
+// Next advances to the next user, fetching the next page when needed. It
+// returns false after an empty page or the last of TotalPages, once ctx is
+// done or when a call failed.
+func (it *UserIterator) Next(ctx context.Context) bool {
+	if it.done {
+		return false
+	}
+	if err := ctx.Err(); err != nil {
+		return it.stop(err)
+	}
+	for it.page == nil || it.index >= len(it.page.Users) {
+		if it.page != nil && it.pageNumber >= it.page.TotalPages {
+			return it.stop(nil)
+		}
+		it.pageNumber++
+		page, err := it.lister.ListUsers(ctx, it.pageNumber, it.perPage)
+		if err != nil {
+			return it.stop(err)
+		}
+		if len(page.Users) == 0 {
+			return it.stop(nil)
+		}
+		it.page, it.index = page, 0
+	}
+	it.user = it.page.Users[it.index]
+	it.index++
+	return true
+}
+
+// User returns the user Next advanced to.
+func (it *UserIterator) User() User {
+	return it.user
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *UserIterator) Err() error {
+	return it.err
+}
+
+func (it *UserIterator) stop(err error) bool {
+	it.done, it.err = true, err
+	return false
+}
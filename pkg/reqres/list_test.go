@@ -0,0 +1,93 @@
+package reqres
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// pagedLister serves users two per page and records the pages requested.
+type pagedLister struct {
+	users      []string
+	totalPages int
+	// echo is the page number put in the response, if not the one asked for.
+	echo      int
+	failOn    int
+	requested []int
+}
+
+func (l *pagedLister) ListUsers(ctx context.Context, page, perPage int) (*UserPage, error) {
+	l.requested = append(l.requested, page)
+	if page == l.failOn {
+		return nil, errors.New("backend failed")
+	}
+	result := &UserPage{Page: page, PerPage: 2, TotalPages: l.totalPages}
+	if l.echo != 0 {
+		result.Page = l.echo
+	}
+	for i := (page - 1) * 2; i < page*2 && i < len(l.users); i++ {
+		result.Users = append(result.Users, User{Id: l.users[i]})
+	}
+	return result, nil
+}
+
+func TestUserIterator(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		lister    *pagedLister
+		ids       []string
+		requested []int
+		err       bool
+	}{{
+		name:      "last of total pages",
+		lister:    &pagedLister{users: []string{"1", "2", "3"}, totalPages: 2},
+		ids:       []string{"1", "2", "3"},
+		requested: []int{1, 2},
+	}, {
+		name:      "server echoing the first page",
+		lister:    &pagedLister{users: []string{"1", "2", "3"}, totalPages: 2, echo: 1},
+		ids:       []string{"1", "2", "3"},
+		requested: []int{1, 2},
+	}, {
+		name:      "empty page before total pages",
+		lister:    &pagedLister{users: []string{"1", "2"}, totalPages: 5},
+		ids:       []string{"1", "2"},
+		requested: []int{1, 2},
+	}, {
+		name:      "no total pages",
+		lister:    &pagedLister{users: []string{"1", "2", "3"}},
+		ids:       []string{"1", "2"},
+		requested: []int{1},
+	}, {
+		name:      "no users",
+		lister:    &pagedLister{totalPages: 1},
+		requested: []int{1},
+	}, {
+		name:      "failed page",
+		lister:    &pagedLister{users: []string{"1", "2", "3"}, totalPages: 2, failOn: 2},
+		ids:       []string{"1", "2"},
+		requested: []int{1, 2},
+		err:       true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			it := NewUserIterator(tc.lister, 2)
+			var ids []string
+			for it.Next(context.Background()) {
+				ids = append(ids, it.User().Id)
+			}
+			if !reflect.DeepEqual(ids, tc.ids) {
+				t.Errorf("users = %v, want %v", ids, tc.ids)
+			}
+			if !reflect.DeepEqual(tc.lister.requested, tc.requested) {
+				t.Errorf("requested pages %v, want %v", tc.lister.requested, tc.requested)
+			}
+			if (it.Err() != nil) != tc.err {
+				t.Errorf("Err() = %v", it.Err())
+			}
+			if it.Next(context.Background()) {
+				t.Error("Next() = true after the end")
+			}
+		})
+	}
+}
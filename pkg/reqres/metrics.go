@@ -0,0 +1,42 @@
+package reqres
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Names of the metrics exported by Client.
+const (
+	MetricRequestDuration = "reqres_client_request_duration_seconds"
+	MetricRetries         = "reqres_client_retries_total"
+	MetricCircuitState    = "reqres_client_circuit_state"
+)
+
+var requestDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    MetricRequestDuration,
+		Help:    "Latency of single backend requests, by operation and HTTP status code, or \"error\" if there was no response.",
+		Buckets: prometheus.DefBuckets,
+	},
+	[]string{"operation", "code"},
+)
+
+var retriesTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: MetricRetries,
+		Help: "Number of backend requests retried, by operation and reason.",
+	},
+	[]string{"operation", "reason"},
+)
+
+var breakerState = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: MetricCircuitState,
+		Help: "State of the circuit breaker of each backend, by the namespace of ReqresBackends: 0 closed, 1 open, 2 half-open.",
+	},
+	[]string{"backend", "namespace"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(requestDuration, retriesTotal, breakerState)
+}
@@ -0,0 +1,514 @@
+package reqres
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// Profile describes a REST API users are managed in, as declared by a
+// BackendProfile.
+type Profile struct {
+	// BaseURL is prepended to the path of every operation.
+	BaseURL string
+	// Attributes names the backend attribute of each field, keyed by
+	// FieldEmail, FieldFirstName, FieldLastName and FieldAvatar.
+	Attributes map[string]string
+	// Headers are sent with every request.
+	Headers map[string]string
+	// SupportsIdempotencyKey sets Client.SupportsIdempotencyKey.
+	SupportsIdempotencyKey bool
+	Create                 ProfileOperation
+	Get                    ProfileOperation
+	Update                 ProfileOperation
+	Delete                 ProfileOperation
+	// List is optional and finds users by email.
+	List *ProfileOperation
+}
+
+// ProfileOperation describes one HTTP call of a Profile. Path and Body are
+// Go templates, the response fields JSONPath expressions.
+type ProfileOperation struct {
+	Method       string
+	Path         string
+	Body         string
+	SuccessCodes []int
+	// User locates the user in the response, defaulting to the whole body.
+	User string
+	// ID locates the id within the user, defaulting to "{.id}".
+	ID string
+	// Items locates the users of a list response.
+	Items string
+	// TotalPages locates the page count of a list response.
+	TotalPages string
+}
+
+// ProfileClient manages users in any REST API described by a BackendProfile,
+// sending its requests through a Client.
+type ProfileClient struct {
+	client     *Client
+	attributes map[string]string
+	header     http.Header
+	create     *profileOperation
+	get        *profileOperation
+	update     *profileOperation
+	delete     *profileOperation
+	list       *profileOperation
+}
+
+// profileOperation is a compiled ProfileOperation.
+type profileOperation struct {
+	op           string
+	method       string
+	path         *template.Template
+	body         *template.Template
+	successCodes []int
+	user         *jsonpath.JSONPath
+	id           *jsonpath.JSONPath
+	items        *jsonpath.JSONPath
+	totalPages   *jsonpath.JSONPath
+}
+
+// profileData is what path and body templates are executed with.
+type profileData struct {
+	ID             string
+	User           User
+	Attributes     map[string]string
+	IdempotencyKey string
+	Page           int
+	PerPage        int
+}
+
+// requiredAttributes are the fields every profile must map, as the USER
+// spec requires them.
+var requiredAttributes = []string{FieldEmail, FieldFirstName}
+
+// NewProfileClient compiles profile. client is copied with the profile's
+// base URL, keeping its transport, timeouts, retry policy, limiter and
+// breaker.
+func NewProfileClient(profile Profile, client *Client) (*ProfileClient, error) {
+	if _, err := url.ParseRequestURI(profile.BaseURL); err != nil {
+		return nil, fmt.Errorf("baseURL: %w", err)
+	}
+	for field := range profile.Attributes {
+		if fieldRuleOf(field) == nil {
+			return nil, fmt.Errorf("attributes: unknown field %q", field)
+		}
+	}
+	for _, field := range requiredAttributes {
+		if profile.Attributes[field] == "" {
+			return nil, fmt.Errorf("attributes: %s is required", field)
+		}
+	}
+	c := *client
+	c.HostUrl = strings.TrimSuffix(profile.BaseURL, "/")
+	c.SupportsIdempotencyKey = profile.SupportsIdempotencyKey
+	p := &ProfileClient{
+		client:     &c,
+		attributes: profile.Attributes,
+		header:     http.Header{},
+	}
+	for key, value := range profile.Headers {
+		p.header.Set(key, value)
+	}
+	var err error
+	if p.create, err = compileOperation(OpCreate, profile.Create); err != nil {
+		return nil, err
+	}
+	if p.get, err = compileOperation(OpGet, profile.Get); err != nil {
+		return nil, err
+	}
+	if p.update, err = compileOperation(OpUpdate, profile.Update); err != nil {
+		return nil, err
+	}
+	if p.delete, err = compileOperation(OpDelete, profile.Delete); err != nil {
+		return nil, err
+	}
+	if profile.List != nil {
+		if p.list, err = compileOperation(OpList, *profile.List); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+func compileOperation(op string, operation ProfileOperation) (*profileOperation, error) {
+	compiled := &profileOperation{
+		op:           op,
+		method:       strings.ToUpper(operation.Method),
+		successCodes: operation.SuccessCodes,
+	}
+	switch compiled.method {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return nil, fmt.Errorf("operations.%s.method: unsupported method %q", op, operation.Method)
+	}
+	if len(compiled.successCodes) == 0 {
+		return nil, fmt.Errorf("operations.%s.successCodes: at least one is required", op)
+	}
+	var err error
+	if compiled.path, err = compileTemplate("operations."+op+".path", operation.Path); err != nil {
+		return nil, err
+	}
+	if operation.Body != "" {
+		if compiled.body, err = compileTemplate("operations."+op+".body", operation.Body); err != nil {
+			return nil, err
+		}
+	}
+	for _, expr := range []struct {
+		name       string
+		expression string
+		fallback   string
+		compiled   **jsonpath.JSONPath
+	}{
+		{"user", operation.User, "", &compiled.user},
+		{"id", operation.ID, "{.id}", &compiled.id},
+		{"items", operation.Items, "", &compiled.items},
+		{"totalPages", operation.TotalPages, "", &compiled.totalPages},
+	} {
+		expression := expr.expression
+		if expression == "" {
+			expression = expr.fallback
+		}
+		if expression == "" {
+			continue
+		}
+		if *expr.compiled, err = compileJSONPath(op+".response."+expr.name, expression); err != nil {
+			return nil, err
+		}
+	}
+	return compiled, nil
+}
+
+func compileTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// compileJSONPath parses expression, which may leave out the braces, like
+// kubectl's -o jsonpath. Missing keys yield no results.
+func compileJSONPath(name, expression string) (*jsonpath.JSONPath, error) {
+	if !strings.HasPrefix(expression, "{") {
+		expression = "{" + expression + "}"
+	}
+	compiled := jsonpath.New(name).AllowMissingKeys(true)
+	if err := compiled.Parse(expression); err != nil {
+		return nil, fmt.Errorf("operations.%s: %w", name, err)
+	}
+	return compiled, nil
+}
+
+// RetryAfter returns how long calls are suspended by the circuit breaker, if
+// at all.
+func (p *ProfileClient) RetryAfter() time.Duration {
+	return p.client.RetryAfter()
+}
+
+// CreateUser creates user. The idempotency key is sent as Idempotency-Key
+// and is available to templates, see Profile.SupportsIdempotencyKey.
+func (p *ProfileClient) CreateUser(ctx context.Context, user User, idempotencyKey string) (*User, error) {
+	data := profileData{User: user, Attributes: p.attributesOf(user, false), IdempotencyKey: idempotencyKey}
+	var header http.Header
+	if idempotencyKey != "" {
+		header = http.Header{"Idempotency-Key": {idempotencyKey}}
+	}
+	res, body, err := p.call(ctx, p.create, data, header)
+	if err != nil {
+		return nil, err
+	}
+	created, err := p.userAt(p.create, body)
+	if err != nil {
+		return nil, newDecodeError(OpCreate, res, err)
+	}
+	if created.Id == "" {
+		return nil, newDecodeError(OpCreate, res, errMissingID)
+	}
+	return &User{Id: created.Id}, nil
+}
+
+func (p *ProfileClient) GetUser(ctx context.Context, id string) (*User, error) {
+	if id == "" {
+		return nil, fmt.Errorf("reqres %s: empty user id: %w", OpGet, ErrPermanent)
+	}
+	res, body, err := p.call(ctx, p.get, profileData{ID: id}, nil)
+	if err != nil {
+		return nil, err
+	}
+	user, err := p.userAt(p.get, body)
+	if err != nil {
+		return nil, newDecodeError(OpGet, res, err)
+	}
+	if user.Id == "" {
+		user.Id = id
+	}
+	return user, nil
+}
+
+// PatchUser sets the fields of diff. Profiles updating with PUT are sent the
+// whole user, read back first, with the diff applied. An empty diff makes no
+// request.
+func (p *ProfileClient) PatchUser(ctx context.Context, id string, diff Diff) error {
+	if id == "" {
+		return fmt.Errorf("reqres %s: empty user id: %w", OpUpdate, ErrPermanent)
+	}
+	if len(diff) == 0 {
+		return nil
+	}
+	user := diff.Apply(User{Id: id})
+	attributes := p.attributesOf(user, true)
+	if p.update.method == http.MethodPut {
+		current, err := p.GetUser(ctx, id)
+		if err != nil {
+			return err
+		}
+		user = diff.Apply(*current)
+		attributes = p.attributesOf(user, false)
+	}
+	_, _, err := p.call(ctx, p.update, profileData{ID: id, User: user, Attributes: attributes}, nil)
+	return err
+}
+
+func (p *ProfileClient) DeleteUser(ctx context.Context, id string) (bool, error) {
+	if id == "" {
+		return false, fmt.Errorf("reqres %s: empty user id: %w", OpDelete, ErrPermanent)
+	}
+	if _, _, err := p.call(ctx, p.delete, profileData{ID: id}, nil); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ListUsers returns a page of users. Without a list operation no users are
+// known, so FindUserByEmail finds none.
+func (p *ProfileClient) ListUsers(ctx context.Context, page, perPage int) (*UserPage, error) {
+	if p.list == nil {
+		return &UserPage{Page: page, PerPage: perPage, TotalPages: page}, nil
+	}
+	res, body, err := p.call(ctx, p.list, profileData{Page: page, PerPage: perPage}, nil)
+	if err != nil {
+		return nil, err
+	}
+	items := []interface{}{body}
+	if p.list.items != nil {
+		if items, err = find(p.list.items, body); err != nil {
+			return nil, newDecodeError(OpList, res, err)
+		}
+	}
+	if len(items) == 1 {
+		if array, ok := items[0].([]interface{}); ok {
+			items = array
+		}
+	}
+	users := make([]User, 0, len(items))
+	for _, item := range items {
+		user, err := p.userAt(p.list, item)
+		if err != nil {
+			return nil, newDecodeError(OpList, res, err)
+		}
+		users = append(users, *user)
+	}
+	result := &UserPage{Users: users, Page: page, PerPage: perPage, TotalPages: page}
+	if p.list.totalPages != nil {
+		value, err := findString(p.list.totalPages, body)
+		if err == nil {
+			result.TotalPages, err = strconv.Atoi(value)
+		}
+		if err != nil {
+			return nil, newDecodeError(OpList, res, fmt.Errorf("totalPages: %w", err))
+		}
+	} else if len(users) > 0 && (perPage == 0 || len(users) >= perPage) {
+		// Without a page count, a full page may be followed by another
+		result.TotalPages = page + 1
+	}
+	return result, nil
+}
+
+// call executes op's templates, sends the request and decodes the JSON
+// response, if any. Statuses other than op's success codes are returned as
+// an APIError.
+func (p *ProfileClient) call(ctx context.Context, op *profileOperation, data profileData, header http.Header) (*response, interface{}, error) {
+	pathData := data
+	pathData.ID = url.PathEscape(data.ID)
+	var path strings.Builder
+	if err := op.path.Execute(&path, pathData); err != nil {
+		return nil, nil, fmt.Errorf("reqres %s: %v: %w", op.op, err, ErrPermanent)
+	}
+	payload, err := op.payload(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reqres %s: %v: %w", op.op, err, ErrPermanent)
+	}
+	requestHeader := p.header.Clone()
+	for key, values := range header {
+		requestHeader[key] = values
+	}
+	res, err := p.client.do(ctx, op.op, op.method, path.String(), payload, requestHeader)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !op.succeeded(res.StatusCode) {
+		return nil, nil, newAPIError(op.op, res)
+	}
+	if len(bytes.TrimSpace(res.body)) == 0 {
+		return res, nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(res.body))
+	decoder.UseNumber()
+	var body interface{}
+	if err := decoder.Decode(&body); err != nil {
+		return nil, nil, newDecodeError(op.op, res, err)
+	}
+	return res, body, nil
+}
+
+// payload is the request body: op's body template, or else a JSON object of
+// the attributes for creates and updates.
+func (op *profileOperation) payload(data profileData) ([]byte, error) {
+	if op.body != nil {
+		var body bytes.Buffer
+		if err := op.body.Execute(&body, data); err != nil {
+			return nil, err
+		}
+		return body.Bytes(), nil
+	}
+	if op.op != OpCreate && op.op != OpUpdate {
+		return nil, nil
+	}
+	return json.Marshal(data.Attributes)
+}
+
+func (op *profileOperation) succeeded(code int) bool {
+	for _, success := range op.successCodes {
+		if code == success {
+			return true
+		}
+	}
+	return false
+}
+
+// attributesOf maps user to backend attributes. With onlySet, or for an
+// empty avatar, fields without a value are left out.
+func (p *ProfileClient) attributesOf(user User, onlySet bool) map[string]string {
+	attributes := make(map[string]string, len(p.attributes))
+	for _, rule := range userFields {
+		attribute, ok := p.attributes[rule.name]
+		if !ok {
+			continue
+		}
+		value := *rule.get(&user)
+		if value == "" && (onlySet || rule.name == FieldAvatar) {
+			continue
+		}
+		attributes[attribute] = value
+	}
+	return attributes
+}
+
+// userAt reads the user out of a response body of op.
+func (p *ProfileClient) userAt(op *profileOperation, body interface{}) (*User, error) {
+	object := body
+	if op.user != nil {
+		results, err := find(op.user, body)
+		if err != nil {
+			return nil, fmt.Errorf("user: %w", err)
+		}
+		if len(results) != 1 {
+			return nil, fmt.Errorf("user: expected one result, found %d", len(results))
+		}
+		object = results[0]
+	}
+	fields, ok := object.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("user: expected an object, found %T", object)
+	}
+	user := &User{}
+	if op.id != nil {
+		id, err := findString(op.id, fields)
+		if err != nil {
+			return nil, fmt.Errorf("id: %w", err)
+		}
+		user.Id = id
+	}
+	for _, rule := range userFields {
+		attribute, ok := p.attributes[rule.name]
+		if !ok {
+			continue
+		}
+		value, err := scalar(fields[attribute])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", attribute, err)
+		}
+		*rule.get(user) = value
+	}
+	return user, nil
+}
+
+// find evaluates expression against data.
+func find(expression *jsonpath.JSONPath, data interface{}) ([]interface{}, error) {
+	results, err := expression.FindResults(data)
+	if err != nil {
+		return nil, err
+	}
+	var values []interface{}
+	for _, result := range results {
+		for _, value := range result {
+			values = append(values, value.Interface())
+		}
+	}
+	return values, nil
+}
+
+// findString evaluates expression against data for a single scalar. A
+// missing one is empty.
+func findString(expression *jsonpath.JSONPath, data interface{}) (string, error) {
+	values, err := find(expression, data)
+	if err != nil {
+		return "", err
+	}
+	switch len(values) {
+	case 0:
+		return "", nil
+	case 1:
+		return scalar(values[0])
+	}
+	return "", fmt.Errorf("expected one result, found %d", len(values))
+}
+
+// scalar renders a JSON scalar as text, keeping numbers as written.
+func scalar(value interface{}) (string, error) {
+	switch value := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	}
+	return "", errors.New("expected a string or number")
+}
+
+func fieldRuleOf(name string) *fieldRule {
+	for i := range userFields {
+		if userFields[i].name == name {
+			return &userFields[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package reqres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// directory is a REST API unlike reqres.in: accounts are wrapped in
+// "account", have numeric uids and are replaced with PUT.
+type directory struct {
+	mu       sync.Mutex
+	accounts map[string]map[string]interface{}
+	nextID   int
+	bodies   []string
+}
+
+func (d *directory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if r.Header.Get("X-Api-Key") != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var body map[string]interface{}
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}
+	raw, _ := json.Marshal(body)
+	d.bodies = append(d.bodies, string(raw))
+	id := strings.TrimPrefix(r.URL.Path, "/v2/accounts/")
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/v2/accounts":
+		d.nextID++
+		account := body["account"].(map[string]interface{})
+		account["uid"] = d.nextID
+		d.accounts[strconv.Itoa(d.nextID)] = account
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"account": account})
+	case r.Method == http.MethodGet && r.URL.Path == "/v2/accounts":
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		results := []interface{}{}
+		for i := (page-1)*limit + 1; i <= d.nextID && len(results) < limit; i++ {
+			if account, ok := d.accounts[strconv.Itoa(i)]; ok {
+				results = append(results, account)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"results": results})
+	case d.accounts[id] == nil:
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == http.MethodGet:
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"account": d.accounts[id]})
+	case r.Method == http.MethodPut:
+		body["uid"], _ = strconv.Atoi(id)
+		d.accounts[id] = body
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete:
+		delete(d.accounts, id)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func directoryProfile(baseURL string) Profile {
+	return Profile{
+		BaseURL: baseURL,
+		Attributes: map[string]string{
+			FieldEmail:     "mail",
+			FieldFirstName: "given_name",
+			FieldLastName:  "family_name",
+		},
+		Headers: map[string]string{"X-Api-Key": "secret"},
+		Create: ProfileOperation{
+			Method:       http.MethodPost,
+			Path:         "/v2/accounts",
+			Body:         `{"account": {{ json .Attributes }}}`,
+			SuccessCodes: []int{http.StatusOK, http.StatusCreated},
+			User:         "{.account}",
+			ID:           "{.uid}",
+		},
+		Get: ProfileOperation{
+			Method:       http.MethodGet,
+			Path:         "/v2/accounts/{{ .ID }}",
+			SuccessCodes: []int{http.StatusOK},
+			User:         "{.account}",
+			ID:           "{.uid}",
+		},
+		Update: ProfileOperation{
+			Method:       http.MethodPut,
+			Path:         "/v2/accounts/{{ .ID }}",
+			SuccessCodes: []int{http.StatusNoContent},
+		},
+		Delete: ProfileOperation{
+			Method:       http.MethodDelete,
+			Path:         "/v2/accounts/{{ .ID }}",
+			SuccessCodes: []int{http.StatusAccepted},
+		},
+		List: &ProfileOperation{
+			Method:       http.MethodGet,
+			Path:         "/v2/accounts?page={{ .Page }}&limit={{ .PerPage }}",
+			SuccessCodes: []int{http.StatusOK},
+			Items:        "{.results}",
+			ID:           "{.uid}",
+		},
+	}
+}
+
+func TestProfileClient(t *testing.T) {
+	ctx := context.Background()
+	api := &directory{accounts: map[string]map[string]interface{}{}}
+	server := httptest.NewServer(api)
+	defer server.Close()
+	client := NewClient("https://reqres.in", nil)
+	client.Retry = NoRetry{}
+	profile, err := NewProfileClient(directoryProfile(server.URL), &client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.HostUrl != "https://reqres.in" {
+		t.Errorf("NewProfileClient changed the client's host to %s", client.HostUrl)
+	}
+
+	created, err := profile.CreateUser(ctx, User{Email: "janet.weaver@reqres.in", FirstName: "Janet", Avatar: "ignored"}, "key-1")
+	if err != nil || created.Id != "1" {
+		t.Fatalf("CreateUser() = %+v, %v", created, err)
+	}
+	if want := `{"account":{"family_name":"","given_name":"Janet","mail":"janet.weaver@reqres.in"}}`; api.bodies[0] != want {
+		t.Errorf("create body = %s, want %s", api.bodies[0], want)
+	}
+
+	diff := Diff{{Field: FieldLastName, Desired: "Weaver"}}
+	if err := profile.PatchUser(ctx, created.Id, diff); err != nil {
+		t.Fatal(err)
+	}
+	got, err := profile.GetUser(ctx, created.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (User{Id: "1", Email: "janet.weaver@reqres.in", FirstName: "Janet", LastName: "Weaver"}); *got != want {
+		t.Errorf("GetUser() = %+v, want %+v", *got, want)
+	}
+
+	if _, err := profile.CreateUser(ctx, User{Email: "emma.wong@reqres.in", FirstName: "Emma"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	page, err := profile.ListUsers(ctx, 1, 1)
+	if err != nil || len(page.Users) != 1 || page.Users[0].Id != "1" || page.TotalPages != 2 {
+		t.Errorf("ListUsers(1, 1) = %+v, %v", page, err)
+	}
+	if found, err := FindUserByEmail(ctx, profile, "Emma.Wong@reqres.in"); err != nil || found.Id != "2" {
+		t.Errorf("FindUserByEmail() = %+v, %v", found, err)
+	}
+
+	if ok, err := profile.DeleteUser(ctx, created.Id); !ok || err != nil {
+		t.Fatalf("DeleteUser() = %v, %v", ok, err)
+	}
+	if _, err := profile.GetUser(ctx, created.Id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetUser of a deleted user: %v", err)
+	}
+	if _, err := profile.GetUser(ctx, ""); !errors.Is(err, ErrPermanent) {
+		t.Errorf("GetUser of an empty id: %v", err)
+	}
+}
+
+func TestNewProfileClient(t *testing.T) {
+	client := NewClient("https://reqres.in", nil)
+	for _, tc := range []struct {
+		name   string
+		mutate func(*Profile)
+	}{{
+		name:   "relative base URL",
+		mutate: func(spec *Profile) { spec.BaseURL = "api.example.com" },
+	}, {
+		name:   "unknown field",
+		mutate: func(spec *Profile) { spec.Attributes["nickname"] = "nick" },
+	}, {
+		name:   "required field not mapped",
+		mutate: func(spec *Profile) { delete(spec.Attributes, FieldFirstName) },
+	}, {
+		name:   "unsupported method",
+		mutate: func(spec *Profile) { spec.Get.Method = "HEAD" },
+	}, {
+		name:   "no success codes",
+		mutate: func(spec *Profile) { spec.Delete.SuccessCodes = nil },
+	}, {
+		name:   "unknown template function",
+		mutate: func(spec *Profile) { spec.Create.Body = "{{ yaml .User }}" },
+	}, {
+		name:   "invalid JSONPath",
+		mutate: func(spec *Profile) { spec.Get.User = "{.account[}" },
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := directoryProfile("https://api.example.com")
+			tc.mutate(&spec)
+			if _, err := NewProfileClient(spec, &client); err == nil {
+				t.Error("NewProfileClient accepted an invalid profile")
+			}
+		})
+	}
+}
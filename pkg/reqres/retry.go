@@ -0,0 +1,104 @@
+package reqres
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy decides whether a failed attempt is tried again and how long to
+// wait before doing so. res is nil when err is a transport error.
+type RetryPolicy interface {
+	// Next is called after the given attempt (starting at 1) failed. elapsed
+	// is the time spent on the operation so far.
+	Next(attempt int, elapsed time.Duration, req *http.Request, res *http.Response, err error) (time.Duration, bool)
+}
+
+// NoRetry makes every call a single attempt.
+type NoRetry struct{}
+
+func (NoRetry) Next(int, time.Duration, *http.Request, *http.Response, error) (time.Duration, bool) {
+	return 0, false
+}
+
+// ExponentialBackoff retries failed requests with exponential backoff and
+// jitter, honouring Retry-After. The Client only asks about requests that
+// are safe to repeat, see Client.SupportsIdempotencyKey.
+type ExponentialBackoff struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Budget caps the total time spent on one operation across attempts.
+	Budget time.Duration
+}
+
+// DefaultRetryPolicy is used by NewClient until overridden.
+var DefaultRetryPolicy = &ExponentialBackoff{
+	MaxAttempts: 4,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+	Budget:      30 * time.Second,
+}
+
+func (p *ExponentialBackoff) Next(attempt int, elapsed time.Duration, req *http.Request, res *http.Response, err error) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || !retryable(res, err) {
+		return 0, false
+	}
+	delay := p.backoff(attempt)
+	if res != nil {
+		if after, ok := retryAfter(res); ok && after > delay {
+			delay = after
+		}
+	}
+	if p.Budget > 0 && elapsed+delay > p.Budget {
+		return 0, false
+	}
+	return delay, true
+}
+
+// backoff returns the base delay doubled per attempt, with equal jitter.
+func (p *ExponentialBackoff) backoff(attempt int) time.Duration {
+	delay := float64(p.BaseDelay) * math.Pow(2, float64(attempt-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	half := time.Duration(delay / 2)
+	if half <= 0 {
+		return 0
+	}
+	return half + time.Duration(rand.Int63n(int64(half)))
+}
+
+// retryable reports whether the failure is worth another attempt.
+func retryable(res *http.Response, err error) bool {
+	return err != nil || retryableStatus(res.StatusCode)
+}
+
+// isDialError reports whether err happened before the request was sent.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// retryAfter parses the Retry-After header, in seconds or as an HTTP date.
+func retryAfter(res *http.Response) (time.Duration, bool) {
+	value := res.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,145 @@
+package reqres
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		value string
+		after time.Duration
+		ok    bool
+	}{
+		{name: "absent"},
+		{name: "seconds", value: "5", after: 5 * time.Second, ok: true},
+		{name: "zero seconds", value: "0", ok: true},
+		{name: "negative seconds", value: "-1"},
+		{name: "past date", value: "Wed, 21 Oct 2015 07:28:00 GMT", ok: true},
+		{name: "garbage", value: "soon"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			res := &http.Response{Header: http.Header{}}
+			if tc.value != "" {
+				res.Header.Set("Retry-After", tc.value)
+			}
+			after, ok := retryAfter(res)
+			if after != tc.after || ok != tc.ok {
+				t.Errorf("retryAfter(%q) = %v, %v, want %v, %v", tc.value, after, ok, tc.after, tc.ok)
+			}
+		})
+	}
+
+	res := &http.Response{Header: http.Header{"Retry-After": {time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}}}
+	if after, ok := retryAfter(res); !ok || after < 59*time.Minute || after > time.Hour {
+		t.Errorf("retryAfter(date in an hour) = %v, %v", after, ok)
+	}
+}
+
+func TestExponentialBackoffNext(t *testing.T) {
+	policy := &ExponentialBackoff{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: 10 * time.Second, Budget: 30 * time.Second}
+	for _, tc := range []struct {
+		name       string
+		status     int
+		retryAfter string
+		err        error
+		attempt    int
+		elapsed    time.Duration
+		ok         bool
+		// delay is the exact delay expected, otherwise the jittered backoff
+		// of attempt is.
+		delay time.Duration
+	}{
+		{name: "server error", status: http.StatusInternalServerError, attempt: 1, ok: true},
+		{name: "request timeout", status: http.StatusRequestTimeout, attempt: 2, ok: true},
+		{name: "transport error", err: errors.New("connection reset"), attempt: 1, ok: true},
+		{name: "not found", status: http.StatusNotFound, attempt: 1},
+		{name: "bad request", status: http.StatusBadRequest, attempt: 1},
+		{name: "conflict", status: http.StatusConflict, attempt: 1},
+		{name: "attempts exhausted", status: http.StatusServiceUnavailable, attempt: 3},
+		{name: "retry after", status: http.StatusTooManyRequests, retryAfter: "7", attempt: 1, ok: true, delay: 7 * time.Second},
+		{name: "retry after shorter than backoff", status: http.StatusTooManyRequests, retryAfter: "0", attempt: 1, ok: true},
+		{name: "budget exhausted", status: http.StatusServiceUnavailable, attempt: 1, elapsed: 30 * time.Second},
+		{name: "retry after beyond budget", status: http.StatusTooManyRequests, retryAfter: "20", attempt: 1, elapsed: 15 * time.Second},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var res *http.Response
+			if tc.err == nil {
+				res = &http.Response{StatusCode: tc.status, Header: http.Header{}}
+				if tc.retryAfter != "" {
+					res.Header.Set("Retry-After", tc.retryAfter)
+				}
+			}
+			req := httptest.NewRequest(http.MethodGet, "/api/users/7", nil)
+			delay, ok := policy.Next(tc.attempt, tc.elapsed, req, res, tc.err)
+			if ok != tc.ok {
+				t.Fatalf("Next() retries = %v, want %v", ok, tc.ok)
+			}
+			if !ok {
+				return
+			}
+			if tc.delay != 0 {
+				if delay != tc.delay {
+					t.Errorf("Next() delay = %v, want %v", delay, tc.delay)
+				}
+				return
+			}
+			backoff := policy.BaseDelay << (tc.attempt - 1)
+			if delay < backoff/2 || delay >= backoff {
+				t.Errorf("Next() delay = %v, want within [%v, %v)", delay, backoff/2, backoff)
+			}
+		})
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		method   string
+		status   int
+		attempts int32
+	}{
+		{name: "get not found", method: http.MethodGet, status: http.StatusNotFound, attempts: 1},
+		{name: "get unauthorized", method: http.MethodGet, status: http.StatusUnauthorized, attempts: 1},
+		{name: "get server error", method: http.MethodGet, status: http.StatusInternalServerError, attempts: 3},
+		{name: "get throttled", method: http.MethodGet, status: http.StatusTooManyRequests, attempts: 3},
+		{name: "delete unavailable", method: http.MethodDelete, status: http.StatusServiceUnavailable, attempts: 3},
+		{name: "post bad request", method: http.MethodPost, status: http.StatusBadRequest, attempts: 1},
+		{name: "post server error", method: http.MethodPost, status: http.StatusInternalServerError, attempts: 1},
+		{name: "post throttled", method: http.MethodPost, status: http.StatusTooManyRequests, attempts: 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(tc.status)
+			}))
+			defer server.Close()
+			client := NewClient(server.URL, nil)
+			client.Retry = &ExponentialBackoff{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+			var err error
+			switch tc.method {
+			case http.MethodGet:
+				_, err = client.GetUser(context.Background(), "7")
+			case http.MethodDelete:
+				_, err = client.DeleteUser(context.Background(), "7")
+			case http.MethodPost:
+				_, err = client.CreateUser(context.Background(), User{Email: "janet.weaver@reqres.in"}, "key")
+			}
+			if attempts != tc.attempts {
+				t.Errorf("made %d attempts, want %d", attempts, tc.attempts)
+			}
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tc.status || apiErr.Attempts != int(tc.attempts) {
+				t.Errorf("error = %v, want status %d after %d attempts", err, tc.status, tc.attempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package reqres
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+)
+
+var (
+	spans     = tracetest.NewInMemoryExporter()
+	spansOnce sync.Once
+)
+
+// recordSpans routes spans to the in-memory exporter. The global provider
+// can only be delegated to once, so it is shared by all tests.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	spansOnce.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	spans.Reset()
+	return spans
+}
+
+func TestClientCallsAreTraced(t *testing.T) {
+	exporter := recordSpans(t)
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"id":2,"email":"janet.weaver@reqres.in"}}`))
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, nil)
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	if _, err := client.GetUser(ctx, "2"); err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	parent.End()
+
+	stubs := exporter.GetSpans()
+	if len(stubs) != 2 {
+		t.Fatalf("expected the client span and its parent, got %d spans", len(stubs))
+	}
+	span := stubs[0]
+	if span.Name != "reqres get" {
+		t.Errorf("span name = %q", span.Name)
+	}
+	if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("client span is not a child of the caller's span")
+	}
+	var status int64
+	for _, attr := range span.Attributes {
+		if attr.Key == semconv.HTTPStatusCodeKey {
+			status = attr.Value.AsInt64()
+		}
+	}
+	if status != http.StatusOK {
+		t.Errorf("http.status_code = %d", status)
+	}
+	if !strings.Contains(traceparent, span.SpanContext.TraceID().String()) ||
+		!strings.Contains(traceparent, span.SpanContext.SpanID().String()) {
+		t.Errorf("traceparent %q does not carry the client span", traceparent)
+	}
+}
+
+func TestFailedClientCallsAreTracedAsErrors(t *testing.T) {
+	exporter := recordSpans(t)
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	client := NewClient(server.URL, nil)
+
+	if _, err := client.DeleteUser(context.Background(), "2"); err == nil {
+		t.Fatal("expected DeleteUser to fail")
+	}
+
+	stubs := exporter.GetSpans()
+	if len(stubs) != 1 {
+		t.Fatalf("expected one span, got %d", len(stubs))
+	}
+	if stubs[0].Status.Code != codes.Error {
+		t.Errorf("status = %v, expected an error", stubs[0].Status.Code)
+	}
+}
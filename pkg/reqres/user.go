@@ -2,127 +2,167 @@ package reqres
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
-	"strconv"
+	"net/url"
 )
 
+// User is a backend user. Id is opaque and empty until the user was created.
 type User struct {
-	Id        int
+	Id        string
 	Email     string
 	FirstName string
 	LastName  string
 	Avatar    string
 }
 
+// ID is a backend user id as found in responses, where it may be a JSON
+// string or number. Either way it is kept as the literal text.
+type ID string
+
+func (id *ID) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*id = ""
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*id = ID(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("user id must be a string or number: %w", err)
+	}
+	*id = ID(n)
+	return nil
+}
+
+// body is the request body creating or updating user. An empty avatar is
+// left out, keeping whatever the backend has.
+func (user User) body() map[string]string {
+	body := map[string]string{
+		"email":      user.Email,
+		"first_name": user.FirstName,
+		"last_name":  user.LastName,
+	}
+	if user.Avatar != "" {
+		body["avatar"] = user.Avatar
+	}
+	return body
+}
+
 type UserCreateResponse struct {
-	Id        string `json:"id"`
+	Id        ID     `json:"id"`
 	CreatedAt string `json:"createdAt"`
 }
 
+type UserData struct {
+	Id        ID     `json:"id"`
+	Email     string `json:"email"`
+	FirstName string `json:"first_name,omitempty"`
+	LastName  string `json:"last_name,omitempty"`
+	Avatar    string `json:"avatar,omitempty"`
+}
+
+func (d UserData) user() User {
+	return User{
+		Id:        string(d.Id),
+		Email:     d.Email,
+		FirstName: d.FirstName,
+		LastName:  d.LastName,
+		Avatar:    d.Avatar,
+	}
+}
+
 type UserGetResponse struct {
-	Data struct {
-		Id        int    `json:"id"`
-		Email     string `json:"email"`
-		FirstName string `json:"first_name,omitempty"`
-		LastName  string `json:"last_name,omitempty"`
-		Avatar    string `json:"avatar,omitempty"`
-	} `json:"data"`
+	Data    UserData `json:"data"`
 	Support struct{} `json:"support,omitempty"`
 }
 
 const (
-	notInitialized    = 0
 	httpPostSuccess   = 201
 	httpGetSuccess    = 200
 	httpDeleteSuccess = 204
-	httpPatchSuccess  = 204
 	ctrlFinalizer     = "users.reqres.in/v1alpha1"
 	usersApi          = "/api/users/"
 )
 
-func (c *Client) CreateUser(user User) (*User, error) {
-	postBody, _ := json.Marshal(map[string]string{
-		"email":      user.Email,
-		"first_name": user.FirstName,
-		"last_name":  user.LastName,
-	})
-	body := bytes.NewBuffer(postBody)
-	url := c.HostUrl + usersApi
-	httpReq, _ := http.NewRequest("POST", url, body)
-	res, err := c.HTTPClient.Do(httpReq)
+// CreateUser creates user in the backend. A non-empty idempotencyKey is sent
+// as Idempotency-Key, which lets backends supporting it recognise a repeated
+// create, see SupportsIdempotencyKey.
+func (c *Client) CreateUser(ctx context.Context, user User, idempotencyKey string) (*User, error) {
+	postBody, _ := json.Marshal(user.body())
+	var header http.Header
+	if idempotencyKey != "" {
+		header = http.Header{"Idempotency-Key": {idempotencyKey}}
+	}
+	res, err := c.do(ctx, OpCreate, http.MethodPost, usersApi, postBody, header)
 	if err != nil {
 		return nil, err
 	}
-	defer res.Body.Close()
 	if res.StatusCode != httpPostSuccess {
-		return nil, fmt.Errorf("http status: %d", res.StatusCode)
+		return nil, newAPIError(OpCreate, res)
 	}
 	var response UserCreateResponse
-	resBody, _ := ioutil.ReadAll(res.Body)
-	json.Unmarshal(resBody, &response)
-	id, _ := strconv.Atoi(response.Id)
-	return &User{Id: id}, nil
+	if err := json.Unmarshal(res.body, &response); err != nil {
+		return nil, newDecodeError(OpCreate, res, err)
+	}
+	if response.Id == "" {
+		return nil, newDecodeError(OpCreate, res, errMissingID)
+	}
+	return &User{Id: string(response.Id)}, nil
 }
 
-func (c *Client) UpdateUser(user User) error {
-	postBody, _ := json.Marshal(map[string]string{
-		"email":      user.Email,
-		"first_name": user.FirstName,
-		"last_name":  user.LastName,
-	})
-	api := usersApi + strconv.Itoa(user.Id)
-	url := c.HostUrl + api
-	body := bytes.NewBuffer(postBody)
-	httpReq, _ := http.NewRequest("PATCH", url, body)
-	res, err := c.HTTPClient.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("error making http request")
-	}
-	defer res.Body.Close()
-	if res.StatusCode != httpPatchSuccess {
-		return fmt.Errorf("http status: %d", res.StatusCode)
+// errMissingID is wrapped in a DecodeError when a created user has no id.
+var errMissingID = errors.New("response carries no user id")
+
+// userPath returns the path of the user with the given id. An empty id would
+// address the collection instead, so it is refused.
+func userPath(op, id string) (string, error) {
+	if id == "" {
+		return "", fmt.Errorf("reqres %s: empty user id: %w", op, ErrPermanent)
 	}
-	return nil
+	return usersApi + url.PathEscape(id), nil
 }
 
-func (c *Client) GetUser(id int) (*User, error) {
-	api := usersApi + strconv.Itoa(id)
-	url := c.HostUrl + api
-	httpReq, _ := http.NewRequest("GET", url, nil)
-	res, err := c.HTTPClient.Do(httpReq)
+func (c *Client) GetUser(ctx context.Context, id string) (*User, error) {
+	api, err := userPath(OpGet, id)
 	if err != nil {
-		return nil, fmt.Errorf("error making http request")
+		return nil, err
+	}
+	res, err := c.do(ctx, OpGet, http.MethodGet, api, nil, nil)
+	if err != nil {
+		return nil, err
 	}
-	defer res.Body.Close()
 	if res.StatusCode != httpGetSuccess {
-		return nil, fmt.Errorf("http status: %d", res.StatusCode)
+		return nil, newAPIError(OpGet, res)
 	}
-	resBody, _ := ioutil.ReadAll(res.Body)
 	var userGetResponse UserGetResponse
-	json.Unmarshal(resBody, &userGetResponse)
-	return &User{
-		Id:        userGetResponse.Data.Id,
-		Email:     userGetResponse.Data.Email,
-		FirstName: userGetResponse.Data.FirstName,
-		LastName:  userGetResponse.Data.LastName,
-		Avatar:    userGetResponse.Data.Avatar,
-	}, nil
+	if err := json.Unmarshal(res.body, &userGetResponse); err != nil {
+		return nil, newDecodeError(OpGet, res, err)
+	}
+	user := userGetResponse.Data.user()
+	return &user, nil
 }
 
-func (c *Client) DeleteUser(id int) (bool, error) {
-	api := usersApi + strconv.Itoa(id)
-	url := c.HostUrl + api
-	httpReq, _ := http.NewRequest("DELETE", url, nil)
-	res, err := c.HTTPClient.Do(httpReq)
+func (c *Client) DeleteUser(ctx context.Context, id string) (bool, error) {
+	api, err := userPath(OpDelete, id)
+	if err != nil {
+		return false, err
+	}
+	res, err := c.do(ctx, OpDelete, http.MethodDelete, api, nil, nil)
 	if err != nil {
-		return false, fmt.Errorf("error making http request")
+		return false, err
 	}
-	defer res.Body.Close()
 	if res.StatusCode != httpDeleteSuccess {
-		return false, fmt.Errorf("http status: %d", res.StatusCode)
+		return false, newAPIError(OpDelete, res)
 	}
 	return true, nil
 }
@@ -0,0 +1,116 @@
+package reqres
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCreateUserRetry(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		status   int
+		key      string
+		supports bool
+		attempts int32
+	}{{
+		name:     "server error",
+		status:   http.StatusServiceUnavailable,
+		key:      "key",
+		attempts: 1,
+	}, {
+		name:     "server error with a supported idempotency key",
+		status:   http.StatusServiceUnavailable,
+		key:      "key",
+		supports: true,
+		attempts: 2,
+	}, {
+		name:     "server error without idempotency key",
+		status:   http.StatusServiceUnavailable,
+		supports: true,
+		attempts: 1,
+	}, {
+		name:     "throttled",
+		status:   http.StatusTooManyRequests,
+		attempts: 2,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&attempts, 1) == 1 {
+					w.WriteHeader(tc.status)
+					return
+				}
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{"id":"7"}`))
+			}))
+			defer server.Close()
+			client := NewClient(server.URL, nil)
+			client.Retry = &ExponentialBackoff{MaxAttempts: 3, BaseDelay: time.Millisecond}
+			client.SupportsIdempotencyKey = tc.supports
+
+			created, err := client.CreateUser(context.Background(), User{Email: "janet.weaver@reqres.in"}, tc.key)
+			if attempts != tc.attempts {
+				t.Errorf("made %d attempts, want %d", attempts, tc.attempts)
+			}
+			if tc.attempts > 1 && (err != nil || created.Id != "7") {
+				t.Errorf("CreateUser() = %+v, %v", created, err)
+			}
+			if tc.attempts == 1 && StatusCode(err) != tc.status {
+				t.Errorf("CreateUser() error = %v, want status %d", err, tc.status)
+			}
+		})
+	}
+
+	// A create that never left is always retried
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	client := NewClient("http://"+addr, nil)
+	client.Retry = &ExponentialBackoff{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	_, err = client.CreateUser(context.Background(), User{Email: "janet.weaver@reqres.in"}, "")
+	var transportErr *TransportError
+	if !errors.As(err, &transportErr) || transportErr.Attempts != 3 {
+		t.Errorf("CreateUser() against a closed port = %v, want 3 attempts", err)
+	}
+}
+
+func TestIDUnmarshalJSON(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		json    string
+		want    ID
+		wantErr bool
+	}{
+		{name: "number", json: `7`, want: "7"},
+		{name: "large number", json: `12345678901234567890`, want: "12345678901234567890"},
+		{name: "string", json: `"7"`, want: "7"},
+		{name: "opaque string", json: `"5f0c9a1e-user"`, want: "5f0c9a1e-user"},
+		{name: "null", json: `null`, want: ""},
+		{name: "bool", json: `true`, wantErr: true},
+		{name: "object", json: `{"id":7}`, wantErr: true},
+		{name: "unterminated string", json: `"7`, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			id := ID("previous")
+			err := id.UnmarshalJSON([]byte(tc.json))
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("UnmarshalJSON(%s) = %q, want an error", tc.json, id)
+				}
+				return
+			}
+			if err != nil || id != tc.want {
+				t.Errorf("UnmarshalJSON(%s) = %q, %v, want %q", tc.json, id, err, tc.want)
+			}
+		})
+	}
+}
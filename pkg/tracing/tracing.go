@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+)
+
+// Setup installs the global tracer provider and the W3C trace context
+// propagator. Spans are exported over OTLP/HTTP to
+// OTEL_EXPORTER_OTLP_ENDPOINT, honouring the other OTEL_EXPORTER_OTLP_*
+// variables; without an endpoint the global no-op provider is kept. Tracers
+// taken from otel.Tracer before, e.g. in package variables, delegate to the
+// provider installed. The returned function flushes and stops the exporter.
+func Setup(ctx context.Context, config *viper.Viper) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	endpoint := config.GetString("OTEL_EXPORTER_OTLP_ENDPOINT")
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(
+			sdktrace.TraceIDRatioBased(config.GetFloat64("OTEL_TRACES_SAMPLER_ARG")),
+		)),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(config.GetString("OTEL_SERVICE_NAME")),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}